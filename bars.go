@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"strings"
+)
+
+// Bars drawn next to the stat columns, relative to the largest value of the
+// stat across the plan.
+
+// Width of the bar column, not counting the space before it.
+var barWidth = 10
+
+// Partial blocks for eighths of a column.
+var barEighths = []rune(" ▏▎▍▌▋▊▉")
+
+// BarMaxima holds the largest value of each stat and of each buffers view
+// across the plan.
+type BarMaxima struct {
+	stats   map[StatView]float64
+	buffers map[BuffersView]float64
+}
+
+func NewBarMaxima(explainPlan ExplainPlan) BarMaxima {
+	maxima := BarMaxima{stats: map[StatView]float64{}, buffers: map[BuffersView]float64{}}
+	for _, node := range explainPlan.nodes {
+		for stat := StatView(0); stat < statViewCount; stat++ {
+			maxima.stats[stat] = max(maxima.stats[stat], node.barValue(stat, BuffersShared, explainPlan.analyzed))
+		}
+		for buffersView := BuffersView(0); buffersView < buffersViewCount; buffersView++ {
+			maxima.buffers[buffersView] = max(maxima.buffers[buffersView], node.barValue(DisplayBuffers, buffersView, explainPlan.analyzed))
+		}
+	}
+	return maxima
+}
+
+func (maxima BarMaxima) forView(ctx ProgramContext) float64 {
+	if ctx.StatDisplay == DisplayBuffers {
+		return maxima.buffers[ctx.BuffersDisplay]
+	}
+	return maxima.stats[ctx.StatDisplay]
+}
+
+// barValue is the value the bar of the stat stands for, the value of its
+// column. Times and rows are per loop like in the columns.
+func (node PlanNode) barValue(stat StatView, buffersView BuffersView, analyzed bool) float64 {
+	switch stat {
+	case DisplayTime:
+		return node.Analyzed.TotalTime
+	case DisplayExclusive:
+		return max(0, node.Analyzed.ExclusiveTime)
+	case DisplayRows:
+		if !analyzed {
+			return float64(node.PlanRows)
+		}
+		return float64(node.Analyzed.ActualRows)
+	case DisplayBuffers:
+		value, _ := node.buffersColumns(buffersView)
+		return value
+	case DisplayWAL:
+		return float64(node.Analyzed.WALBytes)
+	case DisplayCost:
+		return node.TotalCost
+	}
+	return 0
+}
+
+// bar draws the fraction of the width with block characters, a value that
+// isn't zero always gets at least an eighth of a column.
+func bar(fraction float64, width int) string {
+	eighths := int(math.Round(min(1, max(0, fraction)) * float64(width*8)))
+	if eighths == 0 && fraction > 0 {
+		eighths = 1
+	}
+
+	var buf strings.Builder
+	buf.WriteString(strings.Repeat("█", eighths/8))
+	if eighths%8 > 0 {
+		buf.WriteRune(barEighths[eighths%8])
+	}
+	buf.WriteString(strings.Repeat(" ", width-(eighths+7)/8))
+	return buf.String()
+}
+
+// barView is the bar column of the node for the current stat, empty when bars
+// are off or the stat has nothing to compare.
+func (node PlanNode) barView(styles Styles, ctx ProgramContext) string {
+	if !ctx.DisplayBars || ctx.StatDisplay == DisplayNothing {
+		return ""
+	}
+
+	fraction := 0.0
+	if maxValue := ctx.BarMaxima.forView(ctx); maxValue > 0 {
+		fraction = node.barValue(ctx.StatDisplay, ctx.BuffersDisplay, ctx.Analyzed) / maxValue
+	}
+	return styles.Everything.Render(" ") + styles.Bracket.Render(bar(fraction, barWidth))
+}
+
+// barSpace is the width taken by the bar column.
+func barSpace(ctx ProgramContext) int {
+	if !ctx.DisplayBars || ctx.StatDisplay == DisplayNothing {
+		return 0
+	}
+	return barWidth + 1
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBar(t *testing.T) {
+	assert.Equal(t, "██████████", bar(1, 10))
+	assert.Equal(t, "          ", bar(0, 10))
+	assert.Equal(t, "▏         ", bar(0.001, 10))
+	assert.Equal(t, "███▎      ", bar(20.70/64.12, 10))
+	assert.Equal(t, "██", bar(2, 2))
+}
+
+func TestBarMaxima(t *testing.T) {
+	data, err := os.ReadFile("./testdata/parallel_workers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	maxima := NewBarMaxima(explainPlan)
+	ctx := ProgramContext{StatDisplay: DisplayRows}
+	assert.Equal(t, float64(3_000), maxima.forView(ctx))
+	// The bars follow the columns, the parallel seq scan ran 38.12ms in each of
+	// its three loops.
+	ctx.StatDisplay = DisplayTime
+	assert.Equal(t, 40.151, maxima.forView(ctx))
+	ctx.StatDisplay = DisplayBuffers
+	ctx.BuffersDisplay = BuffersShared
+	assert.Equal(t, float64(explainPlan.TotalBuffers()), maxima.forView(ctx))
+	ctx.StatDisplay = DisplayNothing
+	assert.Equal(t, 0, barSpace(ctx))
+}
@@ -3,8 +3,12 @@ package main
 import (
 	"context"
 	"slices"
+	"strconv"
+	"time"
 
 	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgconn/ctxwatch"
 )
 
 type Connection struct {
@@ -12,8 +16,17 @@ type Connection struct {
 	connConfig pgx.ConnConfig
 }
 
-func (c *Connection) Connect() error {
-	conn, err := pgx.ConnectConfig(context.Background(), &c.connConfig)
+// Time the server has to stop a cancelled query before the connection is
+// closed.
+var cancelDeadlineDelay = 2 * time.Second
+
+// Connect opens the connection, a statement whose context is cancelled is
+// stopped on the server by a cancel request rather than left running.
+func (c *Connection) Connect(ctx context.Context) error {
+	c.connConfig.BuildContextWatcherHandler = func(pgConn *pgconn.PgConn) ctxwatch.Handler {
+		return &pgconn.CancelRequestContextWatcherHandler{Conn: pgConn, DeadlineDelay: cancelDeadlineDelay}
+	}
+	conn, err := pgx.ConnectConfig(ctx, &c.connConfig)
 	if err != nil {
 		return err
 	}
@@ -21,16 +34,60 @@ func (c *Connection) Connect() error {
 	return nil
 }
 
-func (c Connection) SetSetting(setting Setting) error {
+func (c Connection) SetSetting(ctx context.Context, setting Setting) error {
 	settingSql := setting.Sql()
-	_, err := c.conn.Exec(context.Background(), settingSql)
+	_, err := c.conn.Exec(ctx, settingSql)
 	return err
 }
 
-func (c Connection) ExecuteExplain(query string) (string, error) {
+// Execute runs statements that don't return rows, like the setup statements
+// of a query.
+func (c Connection) Execute(ctx context.Context, statements []string) error {
+	for _, statement := range statements {
+		_, err := c.conn.Exec(ctx, statement)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c Connection) ExecuteExplain(ctx context.Context, query string) (string, error) {
+
+	var explainResult string
+	err := c.conn.QueryRow(ctx, query).Scan(&explainResult)
+	if err != nil {
+		return "", err
+	}
+
+	return explainResult, nil
+}
+
+// ExecuteExplainRolledBack runs the setup statements and the explain in a
+// transaction that is rolled back, so that the changes of EXPLAIN ANALYZE of a
+// data-modifying statement are undone.
+func (c Connection) ExecuteExplainRolledBack(ctx context.Context, setup []string, query string) (string, error) {
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	// Once the context is done the rollback has to go through another one.
+	defer tx.Rollback(context.Background())
+
+	for _, statement := range setup {
+		_, err := tx.Exec(ctx, statement)
+		if err != nil {
+			return "", err
+		}
+	}
 
 	var explainResult string
-	err := c.conn.QueryRow(context.Background(), query).Scan(&explainResult)
+	err = tx.QueryRow(ctx, query).Scan(&explainResult)
+	if err != nil {
+		return "", err
+	}
+
+	err = tx.Rollback(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -42,6 +99,17 @@ func (c Connection) Close() {
 	c.conn.Close(context.Background())
 }
 
+// ServerVersionNum is the version of the server as a number, e.g. 160002 for
+// 16.2.
+func (c Connection) ServerVersionNum() (int, error) {
+	var version string
+	err := c.conn.QueryRow(context.Background(), "show server_version_num").Scan(&version)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(version)
+}
+
 var allowedSettings = []string{"work_mem", "join_collapse_limit", "max_parallel_workers_per_gather", "random_page_cost", "effective_cache_size"}
 
 func (c Connection) ShowAll() ([]Setting, error) {
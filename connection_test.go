@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgproto3"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeServer runs queries until they are cancelled, like pg_sleep, and
+// reports the cancel requests it receives.
+func fakeServer(t *testing.T) (string, chan *pgproto3.CancelRequest) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	cancelRequests := make(chan *pgproto3.CancelRequest, 1)
+	cancelled := make(chan struct{})
+	var once sync.Once
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				backend := pgproto3.NewBackend(conn, conn)
+				startup, err := backend.ReceiveStartupMessage()
+				if err != nil {
+					return
+				}
+				if cancel, ok := startup.(*pgproto3.CancelRequest); ok {
+					cancelRequests <- cancel
+					once.Do(func() { close(cancelled) })
+					return
+				}
+				backend.Send(&pgproto3.AuthenticationOk{})
+				backend.Send(&pgproto3.BackendKeyData{ProcessID: 42, SecretKey: 7})
+				backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+				if err := backend.Flush(); err != nil {
+					return
+				}
+
+				closed := make(chan struct{})
+				go func() {
+					defer close(closed)
+					for {
+						if _, err := backend.Receive(); err != nil {
+							return
+						}
+					}
+				}()
+				select {
+				case <-cancelled:
+					backend.Send(&pgproto3.ErrorResponse{Severity: "ERROR", Code: "57014", Message: "canceling statement due to user request"})
+					backend.Send(&pgproto3.ReadyForQuery{TxStatus: 'I'})
+					backend.Flush()
+					<-closed
+				case <-closed:
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), cancelRequests
+}
+
+func TestCancelSendsCancelRequest(t *testing.T) {
+	addr, cancelRequests := fakeServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	connConfig, err := pgx.ParseConfig(fmt.Sprintf("postgres://pgex@%s:%s/pgex?sslmode=disable", host, port))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pgConn := Connection{connConfig: *connConfig}
+	if err := pgConn.Connect(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer pgConn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	_, err = pgConn.ExecuteExplain(ctx, "explain (analyze, format json) select pg_sleep(60)")
+
+	// The server stopped the query rather than the connection being dropped.
+	var pgErr *pgconn.PgError
+	assert.True(t, errors.As(err, &pgErr), "expected the server to cancel the query, got %v", err)
+	assert.Equal(t, "cancelled", cancelReason(err))
+	assert.False(t, pgConn.conn.IsClosed())
+
+	select {
+	case cancelRequest := <-cancelRequests:
+		assert.Equal(t, uint32(42), cancelRequest.ProcessID)
+		assert.Equal(t, uint32(7), cancelRequest.SecretKey)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no cancel request was sent to the server")
+	}
+}
@@ -9,9 +9,12 @@ type StatView int
 const (
 	DisplayNothing StatView = iota
 	DisplayTime
+	DisplayExclusive
 	DisplayRows
 	DisplayBuffers
+	DisplayWAL
 	DisplayCost
+	statViewCount
 )
 
 func (s StatView) String() string {
@@ -22,20 +25,57 @@ func (s StatView) String() string {
 		return "Rows"
 	case DisplayBuffers:
 		return "Buffers"
+	case DisplayWAL:
+		return "WAL"
 	case DisplayCost:
 		return "Cost"
 	case DisplayTime:
 		return "Time"
+	case DisplayExclusive:
+		return "Exclusive"
+	}
+	return ""
+}
+
+// BuffersView selects the pair of columns shown by the Buffers stat view.
+type BuffersView int
+
+const (
+	BuffersShared BuffersView = iota
+	BuffersDirtied
+	BuffersLocal
+	BuffersTemp
+	BuffersIOTime
+	buffersViewCount
+)
+
+func (b BuffersView) String() string {
+	switch b {
+	case BuffersShared:
+		return "Shared"
+	case BuffersDirtied:
+		return "Dirtied"
+	case BuffersLocal:
+		return "Local"
+	case BuffersTemp:
+		return "Temp"
+	case BuffersIOTime:
+		return "I/O Time"
 	}
 	return ""
 }
 
 type ProgramContext struct {
-	Indent           bool
-	Cursor           int
-	SettingsCursor   int
-	JoinView         bool
-	StatDisplay      StatView
+	Indent         bool
+	Cursor         int
+	SettingsCursor int
+	JoinView       bool
+	StatDisplay    StatView
+	BuffersDisplay BuffersView
+	// Ids of the nodes whose children are hidden.
+	Folded map[int]bool
+	// First node shown when the plan tree doesn't fit on the screen.
+	NodeOffset       int
 	DisplayParallel  bool
 	DisplayNumbers   bool
 	NormalStyle      Styles
@@ -48,8 +88,21 @@ type ProgramContext struct {
 	Width            int
 	Height           int
 	Analyzed         bool
+	Costs            bool
 	DisplaySql       bool
+	DisplayQuery     bool
 	DisplayRelations bool
+	SearchPattern    string
+	// Only display the nodes matching the search pattern and their ancestors.
+	FilterMatches bool
+	// Show the icicle chart of the plan in place of the tree.
+	DisplayIcicle bool
+	// Draw bars next to the stat columns relative to BarMaxima.
+	DisplayBars bool
+	BarMaxima   BarMaxima
+	// Show the EXPLAIN options in place of the settings.
+	DisplayOptions bool
+	OptionsCursor  int
 }
 
 type Styles struct {
@@ -101,9 +154,11 @@ func InitProgramContext() ProgramContext {
 
 func (ctx *ProgramContext) ResetContext(explainPlan ExplainPlan, model Model) {
 	ctx.Cursor = 0
+	ctx.NodeOffset = 0
 	ctx.SettingsCursor = 0
 	ctx.SelectedNode = model.DisplayNodes[ctx.SettingsCursor]
 	ctx.Analyzed = explainPlan.analyzed
+	ctx.Costs = explainPlan.costs
 }
 
 func StatusLineStyles() StatusStyles {
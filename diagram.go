@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Diagrams of the plan tree for design docs, rows flow along the edges from
+// each child to its parent.
+
+var diagramEdgeColors = []string{"#9e9e9e", "#f0a030", "#d9452b"}
+
+// planEdge is the edge from a node to its parent with the rows it produced.
+type planEdge struct {
+	from int
+	to   int
+	rows float64
+}
+
+func planEdges(explainPlan ExplainPlan) []planEdge {
+	edges := []planEdge{}
+	for _, node := range explainPlan.nodes {
+		if node.Position.Parent == 0 {
+			continue
+		}
+		edges = append(edges, planEdge{
+			from: node.Position.Id,
+			to:   node.Position.Parent,
+			rows: edgeRows(node, explainPlan.analyzed),
+		})
+	}
+	return edges
+}
+
+// edgeRows is the number of rows the node hands to its parent over all of
+// its loops, or the planned rows when the plan wasn't analyzed.
+func edgeRows(node PlanNode, analyzed bool) float64 {
+	if analyzed {
+		return float64(node.Analyzed.ActualRows * node.Analyzed.ActualLoops)
+	}
+	return float64(node.PlanRows)
+}
+
+// edgeWeight places the rows of an edge on a 0 to 1 log scale relative to the
+// edge with the most rows.
+func edgeWeight(rows, maxRows float64) float64 {
+	if maxRows <= 1 || rows <= 1 {
+		return 0
+	}
+	return math.Log(rows) / math.Log(maxRows)
+}
+
+func maxEdgeRows(edges []planEdge) float64 {
+	maxRows := 0.0
+	for _, edge := range edges {
+		maxRows = max(maxRows, edge.rows)
+	}
+	return maxRows
+}
+
+func edgeColor(weight float64) string {
+	index := int(weight * float64(len(diagramEdgeColors)))
+	return diagramEdgeColors[min(index, len(diagramEdgeColors)-1)]
+}
+
+func diagramLabel(node PlanNode, analyzed bool) []string {
+	lines := []string{node.label() + node.Name()}
+	if node.RelationName != "" {
+		lines = append(lines, node.RelationLabel())
+	} else if node.CteName != "" {
+		lines = append(lines, node.CteName)
+	} else if node.FunctionName != "" {
+		lines = append(lines, node.FunctionName)
+	}
+	if analyzed {
+		if node.Analyzed.ActualLoops == 0 {
+			lines = append(lines, "never executed")
+		} else {
+			lines = append(lines, fmt.Sprintf("self %.3fms (%.1f%%), loops %s", node.Analyzed.ExclusiveTime, node.Analyzed.ExclusivePercent, formatUnderscores(node.Analyzed.ActualLoops)))
+		}
+	} else {
+		lines = append(lines, fmt.Sprintf("cost %s", formatUnderscoresFloat(node.TotalCost)))
+	}
+	return lines
+}
+
+func edgeLabel(rows float64) string {
+	return formatUnderscores(int(rows)) + " rows"
+}
+
+// ExportDot produces a Graphviz digraph of the plan, render it with
+// `dot -Tsvg`.
+func ExportDot(explainPlan ExplainPlan) string {
+	var buf strings.Builder
+
+	buf.WriteString("digraph plan {\n")
+	buf.WriteString("  rankdir=BT;\n")
+	buf.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\", fontsize=10];\n")
+	buf.WriteString("  edge [fontname=\"Helvetica\", fontsize=9];\n")
+
+	for _, node := range explainPlan.nodes {
+		lines := diagramLabel(node, explainPlan.analyzed)
+		for i, line := range lines {
+			lines[i] = dotEscape(line)
+		}
+		buf.WriteString(fmt.Sprintf("  n%d [label=\"%s\"];\n", node.Position.Id, strings.Join(lines, "\\n")))
+	}
+
+	edges := planEdges(explainPlan)
+	maxRows := maxEdgeRows(edges)
+	for _, edge := range edges {
+		weight := edgeWeight(edge.rows, maxRows)
+		buf.WriteString(fmt.Sprintf("  n%d -> n%d [label=\"%s\", penwidth=%.1f, color=\"%s\"];\n",
+			edge.from, edge.to, edgeLabel(edge.rows), 1+weight*4, edgeColor(weight)))
+	}
+
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func dotEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text)
+}
+
+// ExportMermaid produces a Mermaid flowchart of the plan, which renders in
+// markdown on GitHub.
+func ExportMermaid(explainPlan ExplainPlan) string {
+	var buf strings.Builder
+
+	buf.WriteString("flowchart BT\n")
+
+	for _, node := range explainPlan.nodes {
+		lines := diagramLabel(node, explainPlan.analyzed)
+		for i, line := range lines {
+			lines[i] = mermaidEscape(line)
+		}
+		buf.WriteString(fmt.Sprintf("  n%d[\"%s\"]\n", node.Position.Id, strings.Join(lines, "<br/>")))
+	}
+
+	edges := planEdges(explainPlan)
+	maxRows := maxEdgeRows(edges)
+	for _, edge := range edges {
+		buf.WriteString(fmt.Sprintf("  n%d -->|%s| n%d\n", edge.from, edgeLabel(edge.rows), edge.to))
+	}
+	// Links are styled by their order of declaration.
+	for i, edge := range edges {
+		weight := edgeWeight(edge.rows, maxRows)
+		buf.WriteString(fmt.Sprintf("  linkStyle %d stroke-width:%.1fpx,stroke:%s\n", i, 1+weight*4, edgeColor(weight)))
+	}
+
+	return buf.String()
+}
+
+func mermaidEscape(text string) string {
+	return strings.NewReplacer(`"`, "#quot;", "<", "#lt;", ">", "#gt;", "|", "#124;").Replace(text)
+}
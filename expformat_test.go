@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertYamlMatchesJson(t *testing.T) {
+	yamlData, err := os.ReadFile("./testdata/analyze_no_buffers.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/analyze_no_buffers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	yamlPlan, err := Convert(string(yamlData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan, yamlPlan)
+}
+
+func TestConvertXmlMatchesJson(t *testing.T) {
+	xmlData, err := os.ReadFile("./testdata/analyze_no_buffers.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/analyze_no_buffers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlPlan, err := Convert(string(xmlData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan, xmlPlan)
+}
+
+func TestConvertXmlIOTimings(t *testing.T) {
+	xmlData, err := os.ReadFile("./testdata/io_timings.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/io_timings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	xmlPlan, err := Convert(string(xmlData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan, xmlPlan)
+	assert.Equal(t, 1.804, xmlPlan.nodes[0].Analyzed.TempIOWriteTime)
+}
+
+func TestConvertXmlListItems(t *testing.T) {
+	data, err := os.ReadFile("./testdata/sortkey.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, plan.analyzed)
+	assert.Equal(t, []string{"x"}, plan.nodes[0].PresortKeys)
+	assert.Equal(t, []string{"x", "y"}, plan.nodes[0].SortKeys)
+	assert.Equal(t, "t_x_idx", plan.nodes[1].IndexName)
+}
+
+func TestConvertXmlItemsAreStrings(t *testing.T) {
+	data, err := os.ReadFile("./testdata/verbose_select.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"1"}, plan.nodes[0].Output)
+	assert.Equal(t, 0.01, plan.nodes[0].TotalCost)
+}
+
+func TestDetectStructuredFormats(t *testing.T) {
+	assert.Equal(t, FORMAT_YAML, DetectFormat("- Plan: \n    Node Type: \"Result\""))
+	assert.Equal(t, FORMAT_XML, DetectFormat("<explain xmlns=\"http://www.postgresql.org/2009/explain\">"))
+}
@@ -2,20 +2,63 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os"
+	"regexp"
 	"slices"
+	"strconv"
 	"strings"
 )
 
 type ExplainPlan struct {
-	nodes         []PlanNode
-	analyzed      bool
-	executionTime float64
+	nodes               []PlanNode
+	analyzed            bool
+	executionTime       float64
+	planningTime        float64
+	planningBuffersHit  int
+	planningBuffersRead int
+	triggers            []Trigger
+	jit                 JIT
+	settings            []Setting
+	// The plan has the estimated costs and rows, which EXPLAIN leaves out
+	// with COSTS off.
+	costs bool
 }
 
+type Trigger struct {
+	Name           string
+	ConstraintName string
+	Relation       string
+	Time           float64
+	Calls          int
+}
+
+type JIT struct {
+	Functions int
+	Options   map[string]bool
+	Timing    map[string]float64
+}
+
+func (ep ExplainPlan) TotalTriggerTime() float64 {
+	var total float64
+	for _, trigger := range ep.triggers {
+		total += trigger.Time
+	}
+	return total
+}
+
+// TotalBuffers is the number of shared and local buffers accessed by the
+// query, the counts of the root node include those of all its children.
 func (ep ExplainPlan) TotalBuffers() int {
-	return ep.nodes[0].Analyzed.SharedBuffersHit + ep.nodes[0].Analyzed.SharedBuffersRead
+	return ep.nodes[0].Analyzed.TotalBuffers()
+}
+
+func (ep ExplainPlan) TotalTempBlocks() int {
+	return ep.nodes[0].Analyzed.TempReadBlocks + ep.nodes[0].Analyzed.TempWriteBlocks
+}
+
+func (ep ExplainPlan) TotalIOTime() float64 {
+	return ep.nodes[0].Analyzed.IOReadTime + ep.nodes[0].Analyzed.IOWriteTime
 }
 
 func (ep ExplainPlan) TotalRows() int {
@@ -29,66 +72,381 @@ type ParseContext struct {
 	ParentNestedLoop bool
 	Analyzed         bool
 	HasBuffers       bool
+	Costs            bool
+	// Path of the node within the plan, e.g. "Plans[2].Plans[0]", used in
+	// error messages.
+	Path string
 }
 
-func Convert(explainJson string) ExplainPlan {
-	decoded, executionTime, analyzed := decodeJson(explainJson)
+func Convert(explain string) (ExplainPlan, error) {
+	planObject, err := decodePlan(explain)
+	if err != nil {
+		return ExplainPlan{}, err
+	}
+
+	decoded := planObject["Plan"].(map[string]interface{})
+	executionTime, analyzed := planObject["Execution Time"].(float64)
+	if !analyzed {
+		_, analyzed = decoded["Actual Loops"]
+	}
+
 	nodes := make([]PlanNode, 0, 1)
 	id := 0
 
 	_, hasBuffers := decoded["Shared Read Blocks"]
+	_, costs := decoded["Total Cost"]
 
-	extractPlanNodes(decoded,
+	_, err = extractPlanNodes(decoded,
 		Position{Id: 0, Level: 0, Parent: 0},
 		Position{Id: 0, Level: 0, Parent: 0},
-		ParseContext{Id: &id, Nodes: &nodes, Analyzed: analyzed, HasBuffers: hasBuffers},
+		ParseContext{Id: &id, Nodes: &nodes, Analyzed: analyzed, HasBuffers: hasBuffers, Costs: costs},
 	)
+	if err != nil {
+		return ExplainPlan{}, err
+	}
+
+	if analyzed {
+		computeExclusiveTimes(nodes, executionTime)
+	}
 
-	return ExplainPlan{
+	explainPlan := ExplainPlan{
 		nodes:         nodes,
 		analyzed:      analyzed,
 		executionTime: executionTime,
+		costs:         costs,
+	}
+
+	if err := extractQueryAttributes(planObject, &explainPlan); err != nil {
+		return ExplainPlan{}, err
+	}
+
+	return explainPlan, nil
+}
+
+// extractQueryAttributes reads the attributes of the query as a whole that
+// sit next to the "Plan" attribute.
+func extractQueryAttributes(planObject map[string]interface{}, explainPlan *ExplainPlan) error {
+	explainPlan.planningTime, _ = planObject["Planning Time"].(float64)
+
+	if planning, ok := planObject["Planning"].(map[string]interface{}); ok {
+		hit, _ := planning["Shared Hit Blocks"].(float64)
+		read, _ := planning["Shared Read Blocks"].(float64)
+		explainPlan.planningBuffersHit = int(hit)
+		explainPlan.planningBuffersRead = int(read)
+	}
+
+	if triggers, ok := planObject["Triggers"].([]interface{}); ok {
+		for i, t := range triggers {
+			trigger, ok := t.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("Triggers[%d]: expected object, got %v", i, t)
+			}
+			name, _ := trigger["Trigger Name"].(string)
+			constraintName, _ := trigger["Constraint Name"].(string)
+			relation, _ := trigger["Relation"].(string)
+			time, _ := trigger["Time"].(float64)
+			calls, _ := trigger["Calls"].(float64)
+			explainPlan.triggers = append(explainPlan.triggers, Trigger{
+				Name:           name,
+				ConstraintName: constraintName,
+				Relation:       relation,
+				Time:           time,
+				Calls:          int(calls),
+			})
+		}
+	}
+
+	if settings, ok := planObject["Settings"].(map[string]interface{}); ok {
+		for name, value := range settings {
+			explainPlan.settings = append(explainPlan.settings, Setting{name: name, setting: settingValue(value)})
+		}
+		slices.SortFunc(explainPlan.settings, func(a, b Setting) int {
+			return strings.Compare(a.name, b.name)
+		})
+	}
+
+	if jit, ok := planObject["JIT"].(map[string]interface{}); ok {
+		functions, _ := jit["Functions"].(float64)
+		explainPlan.jit.Functions = int(functions)
+		explainPlan.jit.Options = map[string]bool{}
+		explainPlan.jit.Timing = map[string]float64{}
+
+		options, _ := jit["Options"].(map[string]interface{})
+		for name, value := range options {
+			explainPlan.jit.Options[name], _ = value.(bool)
+		}
+
+		timing, _ := jit["Timing"].(map[string]interface{})
+		for name, value := range timing {
+			switch v := value.(type) {
+			case float64:
+				explainPlan.jit.Timing[name] = v
+			case map[string]interface{}:
+				// Since PG17 Generation is broken down into Deform and Total.
+				explainPlan.jit.Timing[name], _ = v["Total"].(float64)
+			}
+		}
+	}
+
+	return nil
+}
+
+// settingValue formats a setting as Postgres shows it, the XML and text formats
+// don't quote their values so numeric settings are decoded as numbers.
+func settingValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
+
+// computeExclusiveTimes sets the time each node spends on its own, the
+// inclusive time of the node across all loops minus that of its children.
+//
+// Below a Gather the actual times are averages over the participating
+// processes which run concurrently, so the summed time is divided by the
+// number of processes to approximate wall clock time.
+//
+// The time of a CTE is reported by the CTE Scan reading from it rather than by
+// the node the CTE is attached to, so it is subtracted from the first scan of
+// the CTE instead of from its parent.
+func computeExclusiveTimes(nodes []PlanNode, executionTime float64) {
+	inclusive := make([]float64, len(nodes))
+	for i, node := range nodes {
+		inclusive[i] = node.Analyzed.TotalTime * float64(node.Analyzed.ActualLoops)
+		if node.Position.BelowGather {
+			if processes := gatherProcesses(nodes, node); processes > 1 {
+				inclusive[i] = inclusive[i] / float64(processes)
+			}
+		}
+	}
+
+	exclusive := slices.Clone(inclusive)
+	cteScanned := map[string]bool{}
+	for i, node := range nodes {
+		if cteName, ok := strings.CutPrefix(node.SubPlanName, "CTE "); ok {
+			for j, scan := range nodes {
+				if scan.NodeType == "CTE Scan" && scan.CteName == cteName && !cteScanned[cteName] {
+					exclusive[j] -= inclusive[i]
+					cteScanned[cteName] = true
+				}
+			}
+			continue
+		}
+		if node.Position.Parent > 0 {
+			exclusive[node.Position.Parent-1] -= inclusive[i]
+		}
+	}
+
+	totalTime := executionTime
+	if totalTime == 0 && len(nodes) > 0 {
+		totalTime = inclusive[0]
+	}
+
+	for i := range nodes {
+		nodes[i].Analyzed.ExclusiveTime = max(0, exclusive[i])
+		if totalTime > 0 {
+			nodes[i].Analyzed.ExclusivePercent = nodes[i].Analyzed.ExclusiveTime / totalTime * 100
+		}
+	}
+}
+
+// gatherProcesses returns the number of processes, workers plus leader, of the
+// Gather the node runs below.
+func gatherProcesses(nodes []PlanNode, node PlanNode) int {
+	for parent := node.Position.Parent; parent > 0; parent = nodes[parent-1].Position.Parent {
+		if nodes[parent-1].IsGather {
+			return nodes[parent-1].Analyzed.LaunchedWorkers
+		}
+	}
+	return 1
+}
+
+type PlanFormat int
+
+const (
+	FORMAT_JSON PlanFormat = iota
+	FORMAT_TEXT
+	FORMAT_YAML
+	FORMAT_XML
+)
+
+// DetectFormat guesses the EXPLAIN format of data from its first characters.
+func DetectFormat(data string) PlanFormat {
+	trimmed := strings.TrimSpace(data)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		return FORMAT_JSON
+	} else if strings.HasPrefix(trimmed, "<") {
+		return FORMAT_XML
+	} else if strings.HasPrefix(trimmed, "- ") {
+		return FORMAT_YAML
+	}
+	return FORMAT_TEXT
+}
+
+var psqlFooterRegex = regexp.MustCompile(`^\(\d+ rows?\)$`)
+var psqlRuleRegex = regexp.MustCompile(`^[-─+┼]+$`)
+
+// cleanPsqlOutput removes the header, footer and line continuation markers
+// psql adds when an explain is copied from its aligned output.
+func cleanPsqlOutput(data string) string {
+	lines := strings.Split(data, "\n")
+	continued, nonEmpty := 0, 0
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			nonEmpty++
+		}
+		if strings.HasSuffix(strings.TrimRight(line, " "), "+") {
+			continued++
+		}
+	}
+	hasContinuations := continued > 0 && continued*2 >= nonEmpty
+
+	cleaned := make([]string, 0, len(lines))
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "QUERY PLAN" || psqlRuleRegex.MatchString(trimmed) || psqlFooterRegex.MatchString(trimmed) {
+			continue
+		}
+		if hasContinuations {
+			line = strings.TrimSuffix(strings.TrimRight(line, " "), "+")
+		}
+		cleaned = append(cleaned, strings.TrimRight(line, " \r"))
+	}
+	return strings.Join(cleaned, "\n")
+}
+
+func decodePlan(data string) (map[string]interface{}, error) {
+	data = cleanPsqlOutput(data)
+
+	var planObject map[string]interface{}
+	var err error
+	switch DetectFormat(data) {
+	case FORMAT_TEXT:
+		planObject, err = decodeText(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing text explain: %w", err)
+		}
+	case FORMAT_YAML:
+		planObject, err = decodeYaml(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing yaml explain: %w", err)
+		}
+	case FORMAT_XML:
+		planObject, err = decodeXml(data)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing xml explain: %w", err)
+		}
+	default:
+		planObject, err = decodeJson(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if _, ok := planObject["Plan"].(map[string]interface{}); !ok {
+		return nil, errors.New("unexpected value in explain, expected 'Plan' attribute")
 	}
+
+	return planObject, nil
 }
 
-func decodeJson(data string) (map[string]interface{}, float64, bool) {
+func decodeJson(data string) (map[string]interface{}, error) {
 	var decoded any
 
 	err := json.Unmarshal([]byte(data), &decoded)
 
 	if err != nil {
-		fmt.Fprintln(os.Stderr, "Error parsing json:", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("error parsing json: %w", err)
 	}
 
 	planJson, ok := decoded.([]interface{})
-	if !ok && len(planJson) != 1 {
-		fmt.Fprintf(os.Stderr, "Unexpected value in json, expected array: %v\n", decoded)
-		os.Exit(1)
+	if !ok || len(planJson) != 1 {
+		return nil, errors.New("unexpected value in json, expected array with a single query")
 	}
 
 	planObject, ok := planJson[0].(map[string]interface{})
 	if !ok {
-		fmt.Fprintf(os.Stderr, "Unexpected value in json, expected object: %v\n", planJson[0])
-		os.Exit(1)
+		return nil, errors.New("unexpected value in json, expected object in array")
 	}
 
-	plan, ok := planObject["Plan"].(map[string]interface{})
+	return planObject, nil
+}
+
+// requiredString returns the string attribute key of plan or an error naming
+// the attribute and the path of the node in the plan tree.
+func requiredString(plan map[string]interface{}, key string, path string) (string, error) {
+	value, ok := plan[key]
+	if !ok {
+		return "", fmt.Errorf("%s: missing '%s'", path, key)
+	}
+	result, ok := value.(string)
 	if !ok {
-		fmt.Fprintf(os.Stderr, "Unexpected value in json, expected 'Plan' attribute: %v\n", planObject)
-		os.Exit(1)
+		return "", fmt.Errorf("%s: expected string for '%s', got %v", path, key, value)
 	}
+	return result, nil
+}
 
-	executionTime, analyzed := planObject["Execution Time"].(float64)
+func requiredFloat(plan map[string]interface{}, key string, path string) (float64, error) {
+	value, ok := plan[key]
+	if !ok {
+		return 0, fmt.Errorf("%s: missing '%s'", path, key)
+	}
+	result, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("%s: expected number for '%s', got %v", path, key, value)
+	}
+	return result, nil
+}
 
-	return plan, executionTime, analyzed
+// costFloat reads one of the estimates, which are required unless the plan
+// was explained with COSTS off.
+func costFloat(plan map[string]interface{}, key string, path string, costs bool) (float64, error) {
+	if !costs {
+		return 0, nil
+	}
+	return requiredFloat(plan, key, path)
 }
 
-func extractPlanNodes(plan map[string]interface{}, parentPosition Position, parentJoinPosition Position, parseContext ParseContext) PlanNode {
-	nodeType := plan["Node Type"].(string)
-	planRows := plan["Plan Rows"].(float64)
+func stringList(plan map[string]interface{}, key string, path string) ([]string, error) {
+	value, ok := plan[key]
+	if !ok {
+		return nil, nil
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected list for '%s', got %v", path, key, value)
+	}
+	var result []string
+	for i, item := range items {
+		itemString, ok := item.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected string for '%s[%d]', got %v", path, key, i, item)
+		}
+		result = append(result, itemString)
+	}
+	return result, nil
+}
 
-	parallelAware := plan["Parallel Aware"].(bool)
+func extractPlanNodes(plan map[string]interface{}, parentPosition Position, parentJoinPosition Position, parseContext ParseContext) (PlanNode, error) {
+	path := parseContext.Path
+	if path == "" {
+		path = "Plan"
+	}
+
+	nodeType, err := requiredString(plan, "Node Type", path)
+	if err != nil {
+		return PlanNode{}, err
+	}
+	planRows, err := costFloat(plan, "Plan Rows", path, parseContext.Costs)
+	if err != nil {
+		return PlanNode{}, err
+	}
+
+	parallelAware, _ := plan["Parallel Aware"].(bool)
 
 	partialMode, ok := plan["Partial Mode"].(string)
 	if !ok {
@@ -101,6 +459,16 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 		relationName = ""
 	}
 
+	schema, ok := plan["Schema"].(string)
+	if !ok {
+		schema = ""
+	}
+
+	alias, ok := plan["Alias"].(string)
+	if !ok {
+		alias = ""
+	}
+
 	indexName, ok := plan["Index Name"].(string)
 	if !ok {
 		indexName = ""
@@ -166,40 +534,60 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 		hashcond = ""
 	}
 
-	var groupkeys []string
-	groupkeyI, ok := plan["Group Key"].([]interface{})
-	if ok {
-		for _, gi := range groupkeyI {
-			groupkeys = append(groupkeys, gi.(string))
-		}
+	mergecond, ok := plan["Merge Cond"].(string)
+	if !ok {
+		mergecond = ""
 	}
 
-	var sortkeys []string
-	sortkeyI, ok := plan["Sort Key"].([]interface{})
-	if ok {
-		for _, gi := range sortkeyI {
-			sortkeys = append(sortkeys, gi.(string))
-		}
+	joinfilter, ok := plan["Join Filter"].(string)
+	if !ok {
+		joinfilter = ""
 	}
 
-	var presortedkeys []string
-	presortedkeyI, ok := plan["Presorted Key"].([]interface{})
-	if ok {
-		for _, gi := range presortedkeyI {
-			presortedkeys = append(presortedkeys, gi.(string))
-		}
+	recheckcond, ok := plan["Recheck Cond"].(string)
+	if !ok {
+		recheckcond = ""
 	}
 
-	planWidth := plan["Plan Width"].(float64)
+	groupkeys, err := stringList(plan, "Group Key", path)
+	if err != nil {
+		return PlanNode{}, err
+	}
+
+	sortkeys, err := stringList(plan, "Sort Key", path)
+	if err != nil {
+		return PlanNode{}, err
+	}
+
+	presortedkeys, err := stringList(plan, "Presorted Key", path)
+	if err != nil {
+		return PlanNode{}, err
+	}
+
+	output, err := stringList(plan, "Output", path)
+	if err != nil {
+		return PlanNode{}, err
+	}
+
+	planWidth, err := costFloat(plan, "Plan Width", path, parseContext.Costs)
+	if err != nil {
+		return PlanNode{}, err
+	}
 
 	parentRelationship, ok := plan["Parent Relationship"].(string)
 	if !ok {
 		parentRelationship = ""
 	}
 
-	startupCost := plan["Startup Cost"].(float64)
-	totalCost := plan["Total Cost"].(float64)
-	workersPlanned, ok := plan["Workers Planned"].(float64)
+	startupCost, err := costFloat(plan, "Startup Cost", path, parseContext.Costs)
+	if err != nil {
+		return PlanNode{}, err
+	}
+	totalCost, err := costFloat(plan, "Total Cost", path, parseContext.Costs)
+	if err != nil {
+		return PlanNode{}, err
+	}
+	workersPlanned, _ := plan["Workers Planned"].(float64)
 
 	plans := plan["Plans"]
 
@@ -245,6 +633,9 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 		Position:           newPosition,
 		JoinViewPosition:   joinViewPosition,
 		RelationName:       relationName,
+		Schema:             schema,
+		Alias:              alias,
+		Output:             output,
 		IsGather:           isGather,
 		StartupCost:        startupCost,
 		TotalCost:          totalCost,
@@ -253,6 +644,9 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 		IndexCond:          indexCond,
 		Filter:             filter,
 		HashCond:           hashcond,
+		MergeCond:          mergecond,
+		JoinFilter:         joinfilter,
+		RecheckCond:        recheckcond,
 		GroupKey:           groupkeys,
 		SortKeys:           sortkeys,
 		PresortKeys:        presortedkeys,
@@ -271,11 +665,18 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 	}
 
 	if parseContext.Analyzed {
-		actualRows := plan["Actual Rows"].(float64)
-		startupTime := plan["Actual Startup Time"].(float64)
-		totalTime := plan["Actual Total Time"].(float64)
+		actualRows, err := requiredFloat(plan, "Actual Rows", path)
+		if err != nil {
+			return PlanNode{}, err
+		}
+		// Timing is absent when the plan was analyzed with timing off.
+		startupTime, _ := plan["Actual Startup Time"].(float64)
+		totalTime, _ := plan["Actual Total Time"].(float64)
 		workersLaunched, _ := plan["Workers Launched"].(float64)
-		actualLoops := plan["Actual Loops"].(float64)
+		actualLoops, err := requiredFloat(plan, "Actual Loops", path)
+		if err != nil {
+			return PlanNode{}, err
+		}
 
 		var workersLaunchedInt int
 		if isGather {
@@ -284,25 +685,119 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 			workersLaunchedInt = 0
 		}
 
+		rowsRemovedByFilter, _ := plan["Rows Removed by Filter"].(float64)
+		rowsRemovedByIndexRecheck, _ := plan["Rows Removed by Index Recheck"].(float64)
+		rowsRemovedByJoinFilter, _ := plan["Rows Removed by Join Filter"].(float64)
+		heapFetches, _ := plan["Heap Fetches"].(float64)
+		exactHeapBlocks, _ := plan["Exact Heap Blocks"].(float64)
+		lossyHeapBlocks, _ := plan["Lossy Heap Blocks"].(float64)
+
+		sortMethod, _ := plan["Sort Method"].(string)
+		sortSpaceUsed, _ := plan["Sort Space Used"].(float64)
+		sortSpaceType, _ := plan["Sort Space Type"].(string)
+
+		hashBuckets, _ := plan["Hash Buckets"].(float64)
+		originalHashBuckets, _ := plan["Original Hash Buckets"].(float64)
+		hashBatches, _ := plan["Hash Batches"].(float64)
+		originalHashBatches, _ := plan["Original Hash Batches"].(float64)
+		peakMemoryUsage, _ := plan["Peak Memory Usage"].(float64)
+
 		analyzed := Analyzed{
-			LaunchedWorkers: workersLaunchedInt,
-			StartupTime:     startupTime,
-			TotalTime:       totalTime,
-			ActualLoops:     int(actualLoops),
-			ActualRows:      int(actualRows),
+			LaunchedWorkers:           workersLaunchedInt,
+			StartupTime:               startupTime,
+			TotalTime:                 totalTime,
+			ActualLoops:               int(actualLoops),
+			ActualRows:                int(actualRows),
+			RowsRemovedByFilter:       int(rowsRemovedByFilter),
+			RowsRemovedByIndexRecheck: int(rowsRemovedByIndexRecheck),
+			RowsRemovedByJoinFilter:   int(rowsRemovedByJoinFilter),
+			HeapFetches:               int(heapFetches),
+			ExactHeapBlocks:           int(exactHeapBlocks),
+			LossyHeapBlocks:           int(lossyHeapBlocks),
+			SortMethod:                sortMethod,
+			SortSpaceUsed:             int(sortSpaceUsed),
+			SortSpaceType:             sortSpaceType,
+			HashBuckets:               int(hashBuckets),
+			OriginalHashBuckets:       int(originalHashBuckets),
+			HashBatches:               int(hashBatches),
+			OriginalHashBatches:       int(originalHashBatches),
+			PeakMemoryUsage:           int(peakMemoryUsage),
+		}
+
+		if workersI, ok := plan["Workers"].([]interface{}); ok {
+			for i, w := range workersI {
+				worker, ok := w.(map[string]interface{})
+				if !ok {
+					return PlanNode{}, fmt.Errorf("%s: expected object for 'Workers[%d]', got %v", path, i, w)
+				}
+				analyzed.Workers = append(analyzed.Workers, extractWorker(worker))
+			}
 		}
 
 		if parseContext.HasBuffers {
-			tempReadBlocks := plan["Temp Read Blocks"].(float64)
-			tempWriteBlocks := plan["Temp Written Blocks"].(float64)
-			sharedReadBlocks := plan["Shared Read Blocks"].(float64)
-			sharedHitBlocks := plan["Shared Hit Blocks"].(float64)
+			tempReadBlocks, err := requiredFloat(plan, "Temp Read Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			tempWriteBlocks, err := requiredFloat(plan, "Temp Written Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			sharedReadBlocks, err := requiredFloat(plan, "Shared Read Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			sharedHitBlocks, err := requiredFloat(plan, "Shared Hit Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			sharedDirtiedBlocks, err := requiredFloat(plan, "Shared Dirtied Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			sharedWrittenBlocks, err := requiredFloat(plan, "Shared Written Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			localHitBlocks, err := requiredFloat(plan, "Local Hit Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			localReadBlocks, err := requiredFloat(plan, "Local Read Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			localDirtiedBlocks, err := requiredFloat(plan, "Local Dirtied Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
+			localWrittenBlocks, err := requiredFloat(plan, "Local Written Blocks", path)
+			if err != nil {
+				return PlanNode{}, err
+			}
 			analyzed.TempReadBlocks = int(tempReadBlocks)
 			analyzed.TempWriteBlocks = int(tempWriteBlocks)
 			analyzed.SharedBuffersHit = int(sharedHitBlocks)
 			analyzed.SharedBuffersRead = int(sharedReadBlocks)
+			analyzed.SharedBuffersDirtied = int(sharedDirtiedBlocks)
+			analyzed.SharedBuffersWritten = int(sharedWrittenBlocks)
+			analyzed.LocalBuffersHit = int(localHitBlocks)
+			analyzed.LocalBuffersRead = int(localReadBlocks)
+			analyzed.LocalBuffersDirtied = int(localDirtiedBlocks)
+			analyzed.LocalBuffersWritten = int(localWrittenBlocks)
 		}
 
+		// I/O timings are only present with track_io_timing on.
+		extractIOTimings(plan, &analyzed)
+
+		// WAL usage is only present with the WAL option.
+		walRecords, _ := plan["WAL Records"].(float64)
+		walFPI, _ := plan["WAL FPI"].(float64)
+		walBytes, _ := plan["WAL Bytes"].(float64)
+		analyzed.WALRecords = int(walRecords)
+		analyzed.WALFPI = int(walFPI)
+		analyzed.WALBytes = int(walBytes)
+
 		extractedNode.Analyzed = analyzed
 	}
 
@@ -317,22 +812,80 @@ func extractPlanNodes(plan map[string]interface{}, parentPosition Position, pare
 		ParentNestedLoop: nodeType == "Nested Loop",
 		Analyzed:         parseContext.Analyzed,
 		HasBuffers:       parseContext.HasBuffers,
+		Costs:            parseContext.Costs,
 	}
 
 	if plans != nil {
-		for _, plan := range plans.([]interface{}) {
-			if plan != nil {
-				extractPlanNodes(
-					plan.(map[string]interface{}),
-					newPosition,
-					joinViewPosition,
-					newParseContext,
-				)
+		childPlans, ok := plans.([]interface{})
+		if !ok {
+			return PlanNode{}, fmt.Errorf("%s: expected list for 'Plans', got %v", path, plans)
+		}
+		for i, childPlan := range childPlans {
+			if childPlan == nil {
+				continue
+			}
+			childPath := fmt.Sprintf("Plans[%d]", i)
+			if parseContext.Path != "" {
+				childPath = parseContext.Path + "." + childPath
+			}
+			child, ok := childPlan.(map[string]interface{})
+			if !ok {
+				return PlanNode{}, fmt.Errorf("%s: expected object, got %v", childPath, childPlan)
+			}
+			newParseContext.Path = childPath
+			_, err := extractPlanNodes(
+				child,
+				newPosition,
+				joinViewPosition,
+				newParseContext,
+			)
+			if err != nil {
+				return PlanNode{}, err
 			}
 		}
 	}
 
-	return extractedNode
+	return extractedNode, nil
+}
+
+func extractIOTimings(plan map[string]interface{}, analyzed *Analyzed) {
+	analyzed.SharedIOReadTime, _ = plan["Shared I/O Read Time"].(float64)
+	analyzed.SharedIOWriteTime, _ = plan["Shared I/O Write Time"].(float64)
+	analyzed.LocalIOReadTime, _ = plan["Local I/O Read Time"].(float64)
+	analyzed.LocalIOWriteTime, _ = plan["Local I/O Write Time"].(float64)
+	analyzed.TempIOReadTime, _ = plan["Temp I/O Read Time"].(float64)
+	analyzed.TempIOWriteTime, _ = plan["Temp I/O Write Time"].(float64)
+
+	// Before PG16 shared and local timings are reported as "I/O Read Time".
+	readTime, _ := plan["I/O Read Time"].(float64)
+	writeTime, _ := plan["I/O Write Time"].(float64)
+
+	analyzed.IOReadTime = readTime + analyzed.SharedIOReadTime + analyzed.LocalIOReadTime + analyzed.TempIOReadTime
+	analyzed.IOWriteTime = writeTime + analyzed.SharedIOWriteTime + analyzed.LocalIOWriteTime + analyzed.TempIOWriteTime
+}
+
+func extractWorker(worker map[string]interface{}) Worker {
+	number, _ := worker["Worker Number"].(float64)
+	startupTime, _ := worker["Actual Startup Time"].(float64)
+	totalTime, _ := worker["Actual Total Time"].(float64)
+	actualRows, _ := worker["Actual Rows"].(float64)
+	actualLoops, _ := worker["Actual Loops"].(float64)
+	sharedHitBlocks, _ := worker["Shared Hit Blocks"].(float64)
+	sharedReadBlocks, _ := worker["Shared Read Blocks"].(float64)
+	tempReadBlocks, _ := worker["Temp Read Blocks"].(float64)
+	tempWriteBlocks, _ := worker["Temp Written Blocks"].(float64)
+
+	return Worker{
+		Number:            int(number),
+		StartupTime:       startupTime,
+		TotalTime:         totalTime,
+		ActualRows:        int(actualRows),
+		ActualLoops:       int(actualLoops),
+		SharedBuffersHit:  int(sharedHitBlocks),
+		SharedBuffersRead: int(sharedReadBlocks),
+		TempReadBlocks:    int(tempReadBlocks),
+		TempWriteBlocks:   int(tempWriteBlocks),
+	}
 }
 
 func isJoinType(nodeType string) bool {
@@ -12,7 +12,10 @@ func TestConvertNoBuffers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.executionTime, 69.662)
 }
 
@@ -21,7 +24,10 @@ func TestConvertNoWriteBuffers(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].Analyzed.TempWriteBlocks, 0)
 }
 
@@ -30,7 +36,10 @@ func TestSubPlanNameProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[1].SubPlanName, "SubPlan 1")
 }
 
@@ -39,7 +48,10 @@ func TestJoinTypeNameProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].JoinType, "Semi")
 }
 
@@ -48,7 +60,10 @@ func TestOperationProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].Operation, "Merge")
 }
 
@@ -57,7 +72,10 @@ func TestTIDCondProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].TidCond, "(ctid = '(0,1)'::tid)")
 }
 
@@ -66,7 +84,10 @@ func TestTableFuncNameProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].TableFunctionName, "xmltable")
 }
 
@@ -75,7 +96,10 @@ func TestCTENAMEProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].CteName, "source")
 }
 
@@ -84,7 +108,10 @@ func TestStrategyProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].NodeType, "SetOp")
 	assert.Equal(t, plan.nodes[0].Strategy, "Hashed")
 	assert.Equal(t, plan.nodes[0].Command, "Except")
@@ -95,7 +122,10 @@ func TestFunctionNameProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, plan.nodes[0].FunctionName, "generate_series")
 }
 
@@ -104,7 +134,10 @@ func TestIncrementalSortProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, []string{"x"}, plan.nodes[0].PresortKeys)
 	assert.Equal(t, []string{"x", "y"}, plan.nodes[0].SortKeys)
 }
@@ -114,6 +147,251 @@ func TestParallelAwareProperty(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	plan := Convert(string(data))
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
 	assert.Equal(t, true, plan.nodes[3].ParallelAware)
 }
+
+func TestConvertMissingAttribute(t *testing.T) {
+	data, err := os.ReadFile("./testdata/missing_total_cost.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Convert(string(data))
+	assert.EqualError(t, err, "Plans[1].Plans[0]: missing 'Total Cost'")
+}
+
+func TestConvertWrongType(t *testing.T) {
+	_, err := Convert(`[{"Plan": {"Node Type": 5}}]`)
+	assert.EqualError(t, err, "Plan: expected string for 'Node Type', got 5")
+}
+
+func TestConvertInvalidJson(t *testing.T) {
+	_, err := Convert(`[{"Plan": `)
+	assert.ErrorContains(t, err, "error parsing json")
+}
+
+func TestExclusiveTimeBelowGather(t *testing.T) {
+	data, err := os.ReadFile("./testdata/analyze_no_buffers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.InDelta(t, 0.002, plan.nodes[0].Analyzed.ExclusiveTime, 0.0001)
+	assert.InDelta(t, 5.054, plan.nodes[1].Analyzed.ExclusiveTime, 0.0001)
+	assert.InDelta(t, 24.409, plan.nodes[2].Analyzed.ExclusiveTime, 0.0001)
+	assert.InDelta(t, 40.151, plan.nodes[3].Analyzed.ExclusiveTime, 0.0001)
+	assert.InDelta(t, 57.64, plan.nodes[3].Analyzed.ExclusivePercent, 0.01)
+}
+
+func TestExclusiveTimeLoopsAndSubPlans(t *testing.T) {
+	data, err := os.ReadFile("./testdata/analyze_buffers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1.103 - sort 0.102 - hash 0.251 - subplan 0.001 * 480
+	assert.InDelta(t, 0.27, plan.nodes[0].Analyzed.ExclusiveTime, 0.0001)
+	// The CTE's time is attributed to its scan, which was never executed.
+	assert.InDelta(t, 0.0, plan.nodes[5].Analyzed.ExclusiveTime, 0.0001)
+	assert.InDelta(t, 0.48, plan.nodes[6].Analyzed.ExclusiveTime, 0.0001)
+}
+
+func TestNodeDetailProperties(t *testing.T) {
+	data, err := os.ReadFile("./testdata/node_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort := plan.nodes[0].Analyzed
+	assert.Equal(t, "external merge", sort.SortMethod)
+	assert.Equal(t, 2552, sort.SortSpaceUsed)
+	assert.Equal(t, "Disk", sort.SortSpaceType)
+
+	join := plan.nodes[1]
+	assert.Equal(t, "(o.total > c.credit_limit)", join.JoinFilter)
+	assert.Equal(t, 2500, join.Analyzed.RowsRemovedByJoinFilter)
+
+	bitmap := plan.nodes[2]
+	assert.Equal(t, "(status = 'open'::text)", bitmap.RecheckCond)
+	assert.Equal(t, 31200, bitmap.Analyzed.RowsRemovedByIndexRecheck)
+	assert.Equal(t, 512, bitmap.Analyzed.ExactHeapBlocks)
+	assert.Equal(t, 1804, bitmap.Analyzed.LossyHeapBlocks)
+
+	hash := plan.nodes[4].Analyzed
+	assert.Equal(t, 65536, hash.HashBuckets)
+	assert.Equal(t, 4, hash.HashBatches)
+	assert.Equal(t, 2, hash.OriginalHashBatches)
+	assert.Equal(t, 1592, hash.PeakMemoryUsage)
+
+	assert.Equal(t, 421, plan.nodes[5].Analyzed.RowsRemovedByFilter)
+}
+
+func TestMergeCondProperty(t *testing.T) {
+	data, err := os.ReadFile("./testdata/subplanname.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "(m1.x = m2.x)", plan.nodes[1].MergeCond)
+}
+
+func TestQueryAttributes(t *testing.T) {
+	data, err := os.ReadFile("./testdata/triggers_jit.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0.214, plan.planningTime)
+	assert.Equal(t, 24, plan.planningBuffersHit)
+	assert.Equal(t, 3, plan.planningBuffersRead)
+	assert.Equal(t, []Trigger{
+		{Name: "orders_audit", Relation: "orders", Time: 1520.442, Calls: 1000000},
+		{Name: "RI_ConstraintTrigger_c_16521", ConstraintName: "orders_customer_id_fkey", Relation: "orders", Time: 310.25, Calls: 1000000},
+	}, plan.triggers)
+	assert.InDelta(t, 1830.692, plan.TotalTriggerTime(), 0.0001)
+	assert.Equal(t, 8, plan.jit.Functions)
+	assert.Equal(t, true, plan.jit.Options["Expressions"])
+	assert.Equal(t, 1.105, plan.jit.Timing["Generation"])
+	assert.Equal(t, 10.35, plan.jit.Timing["Total"])
+}
+
+func TestWorkerProperties(t *testing.T) {
+	data, err := os.ReadFile("./testdata/parallel_workers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, plan.nodes[0].Analyzed.Workers)
+	assert.Equal(t, []Worker{
+		{Number: 0, StartupTime: 0.040, TotalTime: 39.002, ActualRows: 2400, ActualLoops: 1, SharedBuffersHit: 3540},
+		{Number: 1, StartupTime: 0.052, TotalTime: 37.511, ActualRows: 300, ActualLoops: 1, SharedBuffersHit: 443},
+	}, plan.nodes[1].Analyzed.Workers)
+	assert.Equal(t, []int{2400, 300, 300}, plan.nodes[1].participantRows())
+	assert.Equal(t, 2.4, plan.nodes[1].WorkerSkew())
+}
+
+func TestSettingsProperty(t *testing.T) {
+	data, err := os.ReadFile("./testdata/parallel_workers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Setting{
+		{name: "max_parallel_workers_per_gather", setting: "2"},
+		{name: "random_page_cost", setting: "1.1"},
+		{name: "work_mem", setting: "64MB"},
+	}, plan.settings)
+}
+
+func TestSettingValue(t *testing.T) {
+	assert.Equal(t, "64MB", settingValue("64MB"))
+	assert.Equal(t, "1.1", settingValue(1.1))
+	assert.Equal(t, "8", settingValue(float64(8)))
+	assert.Equal(t, "on", settingValue("on"))
+}
+
+func TestBufferProperties(t *testing.T) {
+	data, err := os.ReadFile("./testdata/io_timings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := plan.nodes[0].Analyzed
+	assert.Equal(t, 541, root.LocalBuffersRead)
+	assert.Equal(t, 271, root.TempWriteBlocks)
+	assert.Equal(t, 1.25, root.LocalIOReadTime)
+	assert.InDelta(t, 1.843, root.IOReadTime, 0.0001)
+	assert.InDelta(t, 1.804, root.IOWriteTime, 0.0001)
+	assert.Equal(t, 556, plan.TotalBuffers())
+	assert.Equal(t, 541, plan.TotalTempBlocks())
+	assert.Equal(t, "shared hit=12 read=3, local read=541, temp read=270 written=271", plan.nodes[0].buffersDetail())
+	assert.Equal(t, "local read=1.250ms write=0.000ms", plan.nodes[1].ioTimingsDetail())
+}
+
+func TestIOTimingsBeforePG16(t *testing.T) {
+	var analyzed Analyzed
+	extractIOTimings(map[string]interface{}{
+		"I/O Read Time":       2.5,
+		"I/O Write Time":      0.5,
+		"Temp I/O Read Time":  1.0,
+		"Temp I/O Write Time": 0.25,
+	}, &analyzed)
+	assert.Equal(t, 3.5, analyzed.IOReadTime)
+	assert.Equal(t, 0.75, analyzed.IOWriteTime)
+	assert.Equal(t, 0.0, analyzed.SharedIOReadTime)
+}
+
+func TestWALProperties(t *testing.T) {
+	data, err := os.ReadFile("./testdata/wal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 2048, plan.nodes[0].Analyzed.WALRecords)
+	assert.Equal(t, 37, plan.nodes[0].Analyzed.WALFPI)
+	assert.Equal(t, 412883, plan.nodes[0].Analyzed.WALBytes)
+	assert.Equal(t, 0, plan.nodes[1].Analyzed.WALRecords)
+}
+
+func TestVerboseProperties(t *testing.T) {
+	data, err := os.ReadFile("./testdata/verbose_selfjoin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"o1.id", "o2.id"}, plan.nodes[0].Output)
+	assert.Equal(t, "public", plan.nodes[1].Schema)
+	assert.Equal(t, "o1", plan.nodes[1].Alias)
+	assert.Equal(t, "orders o1", plan.nodes[1].RelationLabel())
+	assert.Equal(t, "orders o2", plan.nodes[3].RelationLabel())
+}
+
+func TestConvertCostsOff(t *testing.T) {
+	data, err := os.ReadFile("./testdata/costs_off.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.False(t, plan.costs)
+	assert.True(t, plan.analyzed)
+	assert.Len(t, plan.nodes, 4)
+	assert.Equal(t, 0.0, plan.nodes[0].TotalCost)
+	assert.Equal(t, 1000, plan.nodes[1].Analyzed.ActualRows)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ExplainOptions are the EXPLAIN options used when executing a query.
+type ExplainOptions struct {
+	Analyze     bool
+	Buffers     bool
+	Timing      bool
+	Verbose     bool
+	WAL         bool
+	Costs       bool
+	Summary     bool
+	Memory      bool
+	Serialize   bool
+	GenericPlan bool
+}
+
+// DefaultExplainOptions explain and analyze the query with buffers, the
+// options used when nothing else is configured.
+func DefaultExplainOptions() ExplainOptions {
+	return ExplainOptions{
+		Analyze: true,
+		Buffers: true,
+		Timing:  true,
+		Costs:   true,
+		Summary: true,
+	}
+}
+
+type explainOption struct {
+	name string
+	// server_version_num of the first postgres release with the option.
+	minVersion int
+	// The option can only be turned on along with ANALYZE.
+	requiresAnalyze bool
+	value           func(options *ExplainOptions) *bool
+}
+
+// The options in the order of the options panel.
+var explainOptions = []explainOption{
+	{"analyze", 0, false, func(o *ExplainOptions) *bool { return &o.Analyze }},
+	{"buffers", 90000, false, func(o *ExplainOptions) *bool { return &o.Buffers }},
+	{"timing", 90200, false, func(o *ExplainOptions) *bool { return &o.Timing }},
+	{"verbose", 0, false, func(o *ExplainOptions) *bool { return &o.Verbose }},
+	{"wal", 130000, true, func(o *ExplainOptions) *bool { return &o.WAL }},
+	{"costs", 90000, false, func(o *ExplainOptions) *bool { return &o.Costs }},
+	{"summary", 100000, false, func(o *ExplainOptions) *bool { return &o.Summary }},
+	{"memory", 170000, false, func(o *ExplainOptions) *bool { return &o.Memory }},
+	{"serialize", 170000, true, func(o *ExplainOptions) *bool { return &o.Serialize }},
+	{"generic_plan", 160000, false, func(o *ExplainOptions) *bool { return &o.GenericPlan }},
+}
+
+func findExplainOption(name string) *explainOption {
+	for i := range explainOptions {
+		if explainOptions[i].name == name {
+			return &explainOptions[i]
+		}
+	}
+	return nil
+}
+
+// Get returns whether the option with the index in explainOptions is on.
+func (o ExplainOptions) Get(index int) bool {
+	return *explainOptions[index].value(&o)
+}
+
+func (o *ExplainOptions) Set(index int, on bool) {
+	*explainOptions[index].value(o) = on
+}
+
+// estimate turns off the options that run the query, for the quick plan shown
+// while the query is analyzed.
+func (o ExplainOptions) estimate() ExplainOptions {
+	o.Analyze = false
+	o.Buffers = false
+	o.Timing = false
+	o.WAL = false
+	o.Summary = false
+	o.Serialize = false
+	return o
+}
+
+// given reports whether the option with the index in explainOptions is passed
+// to EXPLAIN. Options are only given when they differ from their default, so
+// that an option that is off doesn't fail on a server that predates it.
+func (o ExplainOptions) given(index int) bool {
+	switch explainOptions[index].name {
+	case "timing":
+		// Timing is only measured when analyzing.
+		return o.Analyze && !o.Timing
+	case "costs":
+		return !o.Costs
+	case "summary":
+		// The summary is included by default when analyzing.
+		return o.Summary != o.Analyze
+	}
+	return o.Get(index)
+}
+
+// Statement is the start of the EXPLAIN statement with the options.
+func (o ExplainOptions) Statement() string {
+	options := []string{"settings", "format json"}
+	for i, option := range explainOptions {
+		if !o.given(i) {
+			continue
+		}
+		if o.Get(i) {
+			options = append(options, option.name)
+		} else {
+			options = append(options, option.name+" false")
+		}
+	}
+	return fmt.Sprintf("explain (%s) ", strings.Join(options, ", "))
+}
+
+// Unsupported reports whether the server is too old for the option with the
+// index in explainOptions, the version is unknown when 0.
+func Unsupported(index int, serverVersion int) bool {
+	return serverVersion > 0 && explainOptions[index].minVersion > serverVersion
+}
+
+// Validate checks that the options can be used together and on the server,
+// the version isn't checked when 0.
+func (o ExplainOptions) Validate(serverVersion int) error {
+	for i, option := range explainOptions {
+		if !o.given(i) {
+			continue
+		}
+		if Unsupported(i, serverVersion) {
+			return fmt.Errorf("EXPLAIN option %s requires postgres %s, the server runs %s",
+				strings.ToUpper(option.name), postgresVersion(option.minVersion), postgresVersion(serverVersion))
+		}
+		if option.requiresAnalyze && o.Get(i) && !o.Analyze {
+			return fmt.Errorf("EXPLAIN option %s requires ANALYZE", strings.ToUpper(option.name))
+		}
+	}
+	if o.Buffers && !o.Analyze && serverVersion > 0 && serverVersion < 130000 {
+		return errors.New("EXPLAIN option BUFFERS requires ANALYZE before postgres 13")
+	}
+	if o.GenericPlan && o.Analyze {
+		return errors.New("EXPLAIN options ANALYZE and GENERIC_PLAN cannot be used together")
+	}
+	return nil
+}
+
+// postgresVersion formats a server_version_num, e.g. 160002 as 16.2 and
+// 90624 as 9.6.24.
+func postgresVersion(versionNum int) string {
+	if versionNum >= 100000 {
+		return fmt.Sprintf("%d.%d", versionNum/10000, versionNum%10000)
+	}
+	return fmt.Sprintf("%d.%d.%d", versionNum/10000, versionNum/100%100, versionNum%100)
+}
+
+// String lists the options that are on, as recorded in pgex files.
+func (o ExplainOptions) String() string {
+	names := []string{}
+	for i, option := range explainOptions {
+		if o.Get(i) {
+			names = append(names, option.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// ParseExplainOptions reads the options listed by String.
+func ParseExplainOptions(list string) ExplainOptions {
+	var options ExplainOptions
+	for _, name := range strings.Split(list, ",") {
+		if option := findExplainOption(strings.TrimSpace(name)); option != nil {
+			*option.value(&options) = true
+		}
+	}
+	return options
+}
+
+// Section of pgex.conf with the options of the next run, e.g. "wal = true".
+var explainSection = "explain"
+
+// applyExplainConfig sets the options given in the explain section of
+// pgex.conf.
+func (o *ExplainOptions) applyExplainConfig(get func(section, key string) (string, bool)) error {
+	for _, option := range explainOptions {
+		value, ok := get(explainSection, option.name)
+		if !ok {
+			continue
+		}
+		on, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("error while parsing %s property: %w", option.name, err)
+		}
+		*option.value(o) = on
+	}
+	return nil
+}
+
+// SaveExplainOptions writes the options to the explain section of the config
+// file, replacing the section and keeping the rest of the file as it is.
+func SaveExplainOptions(path string, options ExplainOptions) error {
+	body, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	var section strings.Builder
+	section.WriteString("[" + explainSection + "]\n")
+	for i, option := range explainOptions {
+		section.WriteString(fmt.Sprintf("%s = %t\n", option.name, options.Get(i)))
+	}
+
+	lines := []string{}
+	if len(body) > 0 {
+		lines = strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+	}
+	kept := make([]string, 0, len(lines))
+	inSection := false
+	replaced := false
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") {
+			inSection = trimmed == "["+explainSection+"]"
+			if inSection && !replaced {
+				kept = append(kept, strings.TrimRight(section.String(), "\n"))
+				replaced = true
+			}
+		}
+		// Blank lines separate the sections.
+		if !inSection || trimmed == "" {
+			kept = append(kept, line)
+		}
+	}
+	if !replaced {
+		if len(kept) > 0 {
+			kept = append(kept, "")
+		}
+		kept = append(kept, strings.TrimRight(section.String(), "\n"))
+	}
+	return os.WriteFile(path, []byte(strings.Join(kept, "\n")+"\n"), 0666)
+}
+
+// ExplainConfigPath is the config file the options are saved to.
+func ExplainConfigPath() string {
+	if len(cliOptions.configPaths) > 0 {
+		return cliOptions.configPaths[0]
+	}
+	return "./pgex.conf"
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	ini "github.com/vaughan0/go-ini"
+)
+
+func TestExplainStatement(t *testing.T) {
+	assert.Equal(t, "explain (settings, format json, analyze, buffers) ", DefaultExplainOptions().Statement())
+	assert.Equal(t, "explain (settings, format json) ", DefaultExplainOptions().estimate().Statement())
+
+	options := DefaultExplainOptions()
+	options.Timing = false
+	options.Costs = false
+	options.Summary = false
+	options.WAL = true
+	assert.Equal(t, "explain (settings, format json, analyze, buffers, timing false, wal, costs false, summary false) ", options.Statement())
+
+	generic := ExplainOptions{Costs: true, Summary: true, GenericPlan: true}
+	assert.Equal(t, "explain (settings, format json, summary, generic_plan) ", generic.Statement())
+}
+
+func TestExplainOptionsValidate(t *testing.T) {
+	assert.NoError(t, DefaultExplainOptions().Validate(0))
+	assert.NoError(t, DefaultExplainOptions().Validate(90624))
+
+	options := DefaultExplainOptions()
+	options.Memory = true
+	assert.EqualError(t, options.Validate(160002), "EXPLAIN option MEMORY requires postgres 17.0, the server runs 16.2")
+	assert.NoError(t, options.Validate(170000))
+
+	options = DefaultExplainOptions()
+	options.Analyze = false
+	options.WAL = true
+	assert.EqualError(t, options.Validate(160002), "EXPLAIN option WAL requires ANALYZE")
+
+	options = DefaultExplainOptions()
+	options.Analyze = false
+	assert.EqualError(t, options.Validate(120010), "EXPLAIN option BUFFERS requires ANALYZE before postgres 13")
+	assert.NoError(t, options.Validate(130000))
+
+	options = DefaultExplainOptions()
+	options.GenericPlan = true
+	assert.EqualError(t, options.Validate(160002), "EXPLAIN options ANALYZE and GENERIC_PLAN cannot be used together")
+}
+
+func TestExplainOptionsString(t *testing.T) {
+	options := DefaultExplainOptions()
+	options.Verbose = true
+	assert.Equal(t, "analyze, buffers, timing, verbose, costs, summary", options.String())
+	assert.Equal(t, options, ParseExplainOptions(options.String()))
+	assert.Equal(t, ExplainOptions{}, ParseExplainOptions(""))
+}
+
+func TestSaveExplainOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pgex.conf")
+	conf := "[database]\nhost = localhost\n\n[explain]\nwal = true\n\n[other]\nkey = value\n"
+	if err := os.WriteFile(path, []byte(conf), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	options := DefaultExplainOptions()
+	options.Verbose = true
+	assert.NoError(t, SaveExplainOptions(path, options))
+
+	body, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "[database]\nhost = localhost\n\n[explain]\nanalyze = true\nbuffers = true\ntiming = true\nverbose = true\nwal = false\ncosts = true\nsummary = true\nmemory = false\nserialize = false\ngeneric_plan = false\n\n[other]\nkey = value\n", string(body))
+
+	file, err := ini.LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded := ExplainOptions{}
+	assert.NoError(t, loaded.applyExplainConfig(file.Get))
+	assert.Equal(t, options, loaded)
+
+	assert.ErrorContains(t, loaded.applyExplainConfig(func(section, key string) (string, bool) {
+		return "yes please", key == "wal"
+	}), "error while parsing wal property")
+
+	missing := filepath.Join(t.TempDir(), "pgex.conf")
+	assert.NoError(t, SaveExplainOptions(missing, ExplainOptions{Analyze: true}))
+	body, _ = os.ReadFile(missing)
+	assert.Contains(t, string(body), "[explain]\nanalyze = true\nbuffers = false\n")
+}
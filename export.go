@@ -0,0 +1,431 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"slices"
+	"strings"
+)
+
+// Number of nodes listed in each of the top node tables of a report.
+var reportTopNodes = 5
+
+type ExportFormat int
+
+const (
+	EXPORT_MARKDOWN ExportFormat = iota
+	EXPORT_HTML
+	EXPORT_DOT
+	EXPORT_MERMAID
+	EXPORT_SVG
+	EXPORT_FOLDED
+)
+
+var exportFormatNames = map[string]ExportFormat{
+	"markdown": EXPORT_MARKDOWN,
+	"md":       EXPORT_MARKDOWN,
+	"html":     EXPORT_HTML,
+	"dot":      EXPORT_DOT,
+	"mermaid":  EXPORT_MERMAID,
+	"svg":      EXPORT_SVG,
+	"folded":   EXPORT_FOLDED,
+}
+
+func ParseExportFormat(name string) (ExportFormat, error) {
+	format, ok := exportFormatNames[strings.ToLower(name)]
+	if !ok {
+		return EXPORT_MARKDOWN, fmt.Errorf("unknown format '%s', expected markdown, html, dot, mermaid, svg or folded", name)
+	}
+	return format, nil
+}
+
+// Export produces a self-contained report or a diagram of the query run in
+// the format.
+func Export(queryRun QueryRun, format ExportFormat) (string, error) {
+	explainPlan, err := Convert(queryRun.result)
+	if err != nil {
+		return "", err
+	}
+
+	switch format {
+	case EXPORT_HTML:
+		return ExportHtml(queryRun, explainPlan)
+	case EXPORT_DOT:
+		return ExportDot(explainPlan), nil
+	case EXPORT_MERMAID:
+		return ExportMermaid(explainPlan), nil
+	case EXPORT_SVG:
+		return ExportSvg(explainPlan)
+	case EXPORT_FOLDED:
+		return ExportFolded(explainPlan)
+	default:
+		return ExportMarkdown(queryRun, explainPlan)
+	}
+}
+
+// reportTable is a table of the nodes scoring highest on one of the measures
+// used to triage a plan.
+type reportTable struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+}
+
+func reportTitle(queryRun QueryRun) string {
+	if queryRun.pgexPointer != "" {
+		return queryRun.pgexPointer
+	}
+	if queryRun.originalFilename != "" {
+		return queryRun.DisplayName()
+	}
+	return "Query Plan"
+}
+
+// reportSummary lists the timings and totals of the query as label and value
+// pairs.
+func reportSummary(explainPlan ExplainPlan) [][]string {
+	summary := [][]string{}
+	if explainPlan.planningTime > 0 {
+		summary = append(summary, []string{"Planning Time", fmt.Sprintf("%.3fms", explainPlan.planningTime)})
+	}
+	if explainPlan.analyzed {
+		summary = append(summary, []string{"Execution Time", fmt.Sprintf("%.3fms", explainPlan.executionTime)})
+	}
+	if len(explainPlan.triggers) > 0 {
+		summary = append(summary, []string{"Triggers", fmt.Sprintf("%.3fms", explainPlan.TotalTriggerTime())})
+	}
+	if explainPlan.jit.Functions > 0 {
+		summary = append(summary, []string{"JIT", fmt.Sprintf("%.3fms", explainPlan.jit.Timing["Total"])})
+	}
+	if explainPlan.TotalBuffers() > 0 {
+		summary = append(summary, []string{"Buffers", formatUnderscores(explainPlan.TotalBuffers())})
+	}
+	if explainPlan.analyzed {
+		summary = append(summary, []string{"Rows", formatUnderscores(explainPlan.TotalRows())})
+	}
+	if explainPlan.costs {
+		summary = append(summary, []string{"Total Cost", formatUnderscoresFloat(explainPlan.nodes[0].TotalCost)})
+	}
+	return summary
+}
+
+func reportTables(explainPlan ExplainPlan) []reportTable {
+	if !explainPlan.analyzed {
+		return nil
+	}
+
+	nodes := explainPlan.nodes
+	tables := []reportTable{}
+
+	slowest := topNodes(nodes, exclusiveTimeScore)
+	table := reportTable{Title: "Slowest nodes", Headers: []string{"#", "Node", "Self Time", "% of Query"}}
+	for _, node := range slowest {
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprint(node.Position.Id),
+			reportNodeName(node),
+			fmt.Sprintf("%.3fms", node.Analyzed.ExclusiveTime),
+			fmt.Sprintf("%.1f%%", node.Analyzed.ExclusivePercent),
+		})
+	}
+	tables = append(tables, table)
+
+	buffersRead := selfBuffersReadScore(nodes)
+	table = reportTable{Title: "Most buffers read", Headers: []string{"#", "Node", "Read", "Total"}}
+	for _, node := range topNodes(nodes, buffersRead) {
+		if buffersRead(node) == 0 {
+			continue
+		}
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprint(node.Position.Id),
+			reportNodeName(node),
+			formatUnderscores(int(buffersRead(node))),
+			formatUnderscores(node.Analyzed.TotalBuffers()),
+		})
+	}
+	tables = append(tables, table)
+
+	// Without costs there are no estimated rows to compare with.
+	if !explainPlan.costs {
+		return tables
+	}
+	table = reportTable{Title: "Row misestimates", Headers: []string{"#", "Node", "Planned", "Actual", "Estimate"}}
+	for _, node := range topNodes(nodes, misestimateScore) {
+		factor, under := node.RowEstimateFactor()
+		if factor < 1.05 {
+			continue
+		}
+		table.Rows = append(table.Rows, []string{
+			fmt.Sprint(node.Position.Id),
+			reportNodeName(node),
+			formatUnderscores(node.PlanRows),
+			formatUnderscores(node.Analyzed.ActualRows),
+			formatEstimateFactor(factor, under),
+		})
+	}
+	tables = append(tables, table)
+
+	return tables
+}
+
+// topNodes returns the nodes with the highest scores, highest first.
+func topNodes(nodes []PlanNode, score func(node PlanNode) float64) []PlanNode {
+	sorted := slices.Clone(nodes)
+	slices.SortStableFunc(sorted, func(a, b PlanNode) int {
+		if score(a) > score(b) {
+			return -1
+		} else if score(a) < score(b) {
+			return 1
+		}
+		return 0
+	})
+	return sorted[:min(len(sorted), reportTopNodes)]
+}
+
+func reportNodeName(node PlanNode) string {
+	name := node.label() + node.Name()
+	if node.RelationName != "" {
+		name += " on " + node.RelationLabel()
+	} else if node.CteName != "" {
+		name += " on " + node.CteName
+	} else if node.FunctionName != "" {
+		name += " on " + node.FunctionName
+	}
+	return name
+}
+
+// ExportMarkdown reports the plan as Markdown that renders in GitHub issues,
+// the tree is kept in a code block to preserve its alignment.
+func ExportMarkdown(queryRun QueryRun, explainPlan ExplainPlan) (string, error) {
+	stat := DisplayCost
+	if explainPlan.analyzed {
+		stat = DisplayTime
+	}
+	tree, err := RenderPlan(queryRun, Source{sourceType: SOURCE_STDIN}, RenderOptions{Stat: stat, NoColor: true, Width: 100})
+	if err != nil {
+		return "", err
+	}
+
+	var buf strings.Builder
+
+	buf.WriteString(fmt.Sprintf("# %s\n\n", reportTitle(queryRun)))
+
+	buf.WriteString(markdownTable([]string{"Summary", ""}, reportSummary(explainPlan)))
+	buf.WriteString("\n")
+
+	if strings.TrimSpace(queryRun.query) != "" {
+		buf.WriteString("## SQL\n\n```sql\n")
+		buf.WriteString(strings.TrimSpace(queryRun.DisplaySql()))
+		buf.WriteString("\n```\n\n")
+	}
+
+	buf.WriteString("## Plan\n\n```\n")
+	buf.WriteString(tree)
+	buf.WriteString("```\n\n")
+
+	for _, table := range reportTables(explainPlan) {
+		if len(table.Rows) == 0 {
+			continue
+		}
+		buf.WriteString(fmt.Sprintf("## %s\n\n", table.Title))
+		buf.WriteString(markdownTable(table.Headers, table.Rows))
+		buf.WriteString("\n")
+	}
+
+	settings := MergeSettings(queryRun.settings, explainPlan.settings)
+	if len(settings) > 0 {
+		buf.WriteString("## Settings\n\n")
+		buf.WriteString(markdownTable([]string{"Setting", "Value"}, settingRows(settings)))
+		buf.WriteString("\n")
+	}
+
+	return buf.String(), nil
+}
+
+func settingRows(settings []Setting) [][]string {
+	rows := make([][]string, 0, len(settings))
+	for _, setting := range settings {
+		rows = append(rows, []string{setting.name, setting.setting})
+	}
+	return rows
+}
+
+func markdownTable(headers []string, rows [][]string) string {
+	var buf strings.Builder
+	buf.WriteString("| " + strings.Join(headers, " | ") + " |\n")
+	buf.WriteString("|" + strings.Repeat(" --- |", len(headers)) + "\n")
+	for _, row := range rows {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+		buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return buf.String()
+}
+
+// htmlNode is a plan node with its children for the collapsible tree.
+type htmlNode struct {
+	Name     string
+	Stats    string
+	Details  []string
+	Children []*htmlNode
+}
+
+type htmlReport struct {
+	Title    string
+	Summary  [][]string
+	Query    string
+	Tree     *htmlNode
+	Tables   []reportTable
+	Settings [][]string
+}
+
+// ExportHtml reports the plan as a single HTML file, the tree is made of
+// nested details elements so that it can be collapsed without any script.
+func ExportHtml(queryRun QueryRun, explainPlan ExplainPlan) (string, error) {
+	report := htmlReport{
+		Title:    reportTitle(queryRun),
+		Summary:  reportSummary(explainPlan),
+		Query:    strings.TrimSpace(queryRun.DisplaySql()),
+		Tree:     htmlTree(explainPlan),
+		Tables:   reportTables(explainPlan),
+		Settings: settingRows(MergeSettings(queryRun.settings, explainPlan.settings)),
+	}
+
+	var buf strings.Builder
+	if err := htmlReportTemplate.Execute(&buf, report); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func htmlTree(explainPlan ExplainPlan) *htmlNode {
+	htmlNodes := make([]*htmlNode, len(explainPlan.nodes))
+	for i, node := range explainPlan.nodes {
+		htmlNodes[i] = &htmlNode{Name: reportNodeName(node), Stats: htmlNodeStats(node, explainPlan.analyzed, explainPlan.costs), Details: htmlNodeDetails(node)}
+		if node.Position.Parent != 0 {
+			parent := htmlNodes[node.Position.Parent-1]
+			parent.Children = append(parent.Children, htmlNodes[i])
+		}
+	}
+	return htmlNodes[0]
+}
+
+func htmlNodeStats(node PlanNode, analyzed bool, costs bool) string {
+	if !analyzed {
+		if !costs {
+			return ""
+		}
+		return fmt.Sprintf("cost %s, rows %s", formatUnderscoresFloat(node.TotalCost), formatUnderscores(node.PlanRows))
+	}
+	if node.Analyzed.ActualLoops == 0 {
+		return "never executed"
+	}
+	// Without costs there are no planned rows to compare with.
+	if !costs {
+		return fmt.Sprintf("self %.3fms (%.1f%%), rows %s, loops %s",
+			node.Analyzed.ExclusiveTime, node.Analyzed.ExclusivePercent,
+			formatUnderscores(node.Analyzed.ActualRows), formatUnderscores(node.Analyzed.ActualLoops))
+	}
+	factor, under := node.RowEstimateFactor()
+	return fmt.Sprintf("self %.3fms (%.1f%%), rows %s of %s planned (%s), loops %s",
+		node.Analyzed.ExclusiveTime, node.Analyzed.ExclusivePercent,
+		formatUnderscores(node.Analyzed.ActualRows), formatUnderscores(node.PlanRows),
+		formatEstimateFactor(factor, under), formatUnderscores(node.Analyzed.ActualLoops))
+}
+
+func htmlNodeDetails(node PlanNode) []string {
+	details := []string{}
+	conditions := []struct {
+		label string
+		value string
+	}{
+		{"Index", node.IndexName},
+		{"Index Cond", node.IndexCond},
+		{"Recheck Cond", node.RecheckCond},
+		{"Hash Cond", node.HashCond},
+		{"Merge Cond", node.MergeCond},
+		{"Join Filter", node.JoinFilter},
+		{"Filter", node.Filter},
+	}
+	for _, condition := range conditions {
+		if condition.value != "" {
+			details = append(details, fmt.Sprintf("%s: %s", condition.label, condition.value))
+		}
+	}
+	if node.SortKeys != nil {
+		details = append(details, "Sort Keys: "+strings.Join(node.SortKeys, ", "))
+	}
+	if buffers := node.buffersDetail(); buffers != "" {
+		details = append(details, "Buffers: "+buffers)
+	}
+	return details
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; margin: 2em; color: #222; }
+pre, .tree { font-family: ui-monospace, SFMono-Regular, Menlo, monospace; font-size: 13px; }
+pre { background: #f4f4f8; padding: 1em; overflow-x: auto; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccd; padding: 0.3em 0.8em; text-align: left; }
+th { background: #eef; }
+.tree details { margin-left: 1.5em; }
+.tree > details { margin-left: 0; }
+.tree summary { cursor: pointer; }
+.tree .leaf { margin-left: 1.5em; list-style: none; }
+.name { color: #2a5db0; font-weight: bold; }
+.stats { color: #666; }
+.detail { color: #444; margin-left: 1.2em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<table>
+{{- range .Summary}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+{{- if .Query}}
+<h2>SQL</h2>
+<pre>{{.Query}}</pre>
+{{- end}}
+<h2>Plan</h2>
+<div class="tree">
+{{template "node" .Tree}}
+</div>
+{{- range .Tables}}
+{{- if .Rows}}
+<h2>{{.Title}}</h2>
+<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</table>
+{{- end}}
+{{- end}}
+{{- if .Settings}}
+<h2>Settings</h2>
+<table>
+{{- range .Settings}}
+<tr><th>{{index . 0}}</th><td>{{index . 1}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+{{define "node"}}<details open>
+<summary><span class="name">{{.Name}}</span> <span class="stats">{{.Stats}}</span></summary>
+{{- range .Details}}
+<div class="detail">{{.}}</div>
+{{- end}}
+{{- range .Children}}
+{{template "node" .}}
+{{- end}}
+</details>{{end}}
+`))
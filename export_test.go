@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportMarkdown(t *testing.T) {
+	data, err := os.ReadFile("./testdata/parallel_workers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryRun := QueryRun{result: string(data), query: "select * from orders;\n"}
+	report, err := Export(queryRun, EXPORT_MARKDOWN)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.HasPrefix(report, "# Query Plan\n"))
+	assert.Contains(t, report, "| Execution Time |")
+	assert.Contains(t, report, "```sql\nselect * from orders;\n```")
+	assert.Contains(t, report, "## Plan\n\n```\n")
+	assert.Contains(t, report, "## Slowest nodes")
+	assert.Contains(t, report, "## Settings")
+	assert.NotContains(t, report, "\x1b[")
+}
+
+func TestExportCostsOff(t *testing.T) {
+	data, err := os.ReadFile("./testdata/costs_off.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	titles := []string{}
+	for _, table := range reportTables(explainPlan) {
+		titles = append(titles, table.Title)
+	}
+	assert.Equal(t, []string{"Slowest nodes", "Most buffers read"}, titles)
+
+	queryRun := QueryRun{result: string(data), query: "select * from orders"}
+	for _, format := range []ExportFormat{EXPORT_MARKDOWN, EXPORT_HTML} {
+		report, err := Export(queryRun, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.NotContains(t, report, "Total Cost")
+		assert.NotContains(t, report, "planned")
+		assert.NotContains(t, report, "under")
+	}
+
+	ctx := InitProgramContext()
+	ctx.ResetContext(explainPlan, Model{DisplayNodes: explainPlan.nodes})
+	content := explainPlan.nodes[1].Content(ctx)
+	assert.NotContains(t, content, "Row Estimate")
+	assert.Contains(t, content, "Relation Name")
+}
+
+func TestExportHtml(t *testing.T) {
+	data, err := os.ReadFile("./testdata/node_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryRun := QueryRun{result: string(data), query: "select * from orders where id < 10"}
+	report, err := Export(queryRun, EXPORT_HTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.True(t, strings.HasPrefix(report, "<!DOCTYPE html>"))
+	assert.Contains(t, report, "<pre>select * from orders where id &lt; 10</pre>")
+	explainPlan, _ := Convert(queryRun.result)
+	assert.Equal(t, len(explainPlan.nodes), strings.Count(report, "<details open>"))
+	assert.Contains(t, report, "Bitmap Heap Scan on orders o")
+
+	_, err = ParseExportFormat("pdf")
+	assert.Error(t, err)
+}
+
+func TestExportDot(t *testing.T) {
+	data, err := os.ReadFile("./testdata/node_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dot := ExportDot(explainPlan)
+
+	assert.True(t, strings.HasPrefix(dot, "digraph plan {\n"))
+	assert.Contains(t, dot, `n3 [label="Bitmap Heap Scan\norders o\nself 62.931ms (26.6%), loops 1"];`)
+	assert.Contains(t, dot, `n3 -> n2 [label="102_500 rows", penwidth=5.0, color="#d9452b"];`)
+	assert.Equal(t, len(explainPlan.nodes)-1, strings.Count(dot, " -> "))
+}
+
+func TestExportMermaid(t *testing.T) {
+	data, err := os.ReadFile("./testdata/verbose_selfjoin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mermaid := ExportMermaid(explainPlan)
+
+	assert.True(t, strings.HasPrefix(mermaid, "flowchart BT\n"))
+	assert.Contains(t, mermaid, `n2["Seq Scan<br/>orders o1<br/>cost 20.70"]`)
+	assert.Contains(t, mermaid, "n4 -->|1_070 rows| n3")
+	assert.Contains(t, mermaid, "linkStyle 2 ")
+}
+
+func TestEdgeWeight(t *testing.T) {
+	assert.Equal(t, 0.0, edgeWeight(1, 1_000))
+	assert.Equal(t, 1.0, edgeWeight(1_000, 1_000))
+	assert.InDelta(t, 0.5, edgeWeight(10_000, 100_000_000), 0.0001)
+	assert.Equal(t, "#9e9e9e", edgeColor(0))
+	assert.Equal(t, "#d9452b", edgeColor(1))
+	assert.Equal(t, "\"a\\\"b\"", "\""+dotEscape(`a"b`)+"\"")
+	assert.Equal(t, "a #gt; b", mermaidEscape("a > b"))
+}
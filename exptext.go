@@ -0,0 +1,685 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// decodeText parses the default TEXT format of EXPLAIN into the same shape
+// that the JSON format decodes to, so that the result can be handed to
+// extractPlanNodes unchanged.
+func decodeText(data string) (map[string]interface{}, error) {
+	parser := textParser{planObject: map[string]interface{}{}}
+
+	for _, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if err := parser.parseLine(line); err != nil {
+			return nil, err
+		}
+	}
+
+	if parser.root == nil {
+		return nil, errors.New("no plan nodes found in text explain")
+	}
+
+	inferParentRelationships(parser.root)
+	if parser.hasBuffers {
+		fillBufferDefaults(parser.root)
+	}
+
+	parser.planObject["Plan"] = parser.root
+	return parser.planObject, nil
+}
+
+type textNode struct {
+	indent int
+	plan   map[string]interface{}
+}
+
+type textParser struct {
+	planObject map[string]interface{}
+	root       map[string]interface{}
+	stack      []textNode
+	// The indent of the root node, everything at or below this indent after
+	// the tree is a query level attribute like "Execution Time".
+	rootIndent int
+	// InitPlan, SubPlan and CTE labels precede the node they describe.
+	pendingLabel string
+	// Lines belonging to a "Worker N:" or "Planning:" block are indented
+	// below the line that opened the block.
+	block       map[string]interface{}
+	blockIndent int
+	hasBuffers  bool
+}
+
+var nodeLineRegex = regexp.MustCompile(`^(.*?)(?:\s+\(cost=([\d.]+)\.\.([\d.]+) rows=(\d+) width=(\d+)\))?(?:\s+\((?:actual (?:time=([\d.]+)\.\.([\d.]+) )?rows=([\d.]+) loops=(\d+)|(never executed))\))?$`)
+var subPlanLabelRegex = regexp.MustCompile(`^(InitPlan|SubPlan|CTE) \S+`)
+var workerRegex = regexp.MustCompile(`^Worker (\d+):\s*(.*)$`)
+var workerActualRegex = regexp.MustCompile(`^actual (?:time=([\d.]+)\.\.([\d.]+) )?rows=([\d.]+) loops=(\d+)$`)
+
+func (p *textParser) parseLine(line string) error {
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	content := strings.TrimSpace(line)
+
+	if strings.HasPrefix(content, "->") {
+		name := strings.TrimSpace(strings.TrimPrefix(content, "->"))
+		return p.addNode(indent, name)
+	}
+
+	if p.root == nil {
+		// Anything else, e.g. a psql error or the query itself, isn't a plan.
+		if match := nodeLineRegex.FindStringSubmatch(content); match == nil || (match[2] == "" && match[8] == "" && match[10] == "") {
+			return fmt.Errorf("no plan node found in text explain, expected a line with (cost=...) or (actual ...) but got: %s", content)
+		}
+		p.rootIndent = indent
+		return p.addNode(indent, content)
+	}
+
+	if p.block != nil && indent > p.blockIndent {
+		p.parseDetail(p.block, content)
+		return nil
+	}
+	p.block = nil
+
+	if indent <= p.rootIndent {
+		return p.parseQueryLine(indent, content)
+	}
+
+	for len(p.stack) > 1 && p.stack[len(p.stack)-1].indent >= indent {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	current := p.stack[len(p.stack)-1].plan
+
+	if subPlanLabelRegex.MatchString(content) && !strings.Contains(content, ":") {
+		p.pendingLabel = content
+		return nil
+	}
+
+	if match := workerRegex.FindStringSubmatch(content); match != nil {
+		p.block = findWorker(current, match[1])
+		p.blockIndent = indent
+		if actual := workerActualRegex.FindStringSubmatch(match[2]); actual != nil {
+			p.block["Actual Startup Time"] = parseTextNumber(actual[1])
+			p.block["Actual Total Time"] = parseTextNumber(actual[2])
+			p.block["Actual Rows"] = parseTextNumber(actual[3])
+			p.block["Actual Loops"] = parseTextNumber(actual[4])
+		} else if match[2] != "" {
+			p.parseDetail(p.block, match[2])
+		}
+		return nil
+	}
+
+	// Only the buffers of the nodes themselves, not those of the Planning
+	// block, mean the nodes report buffers.
+	if strings.HasPrefix(content, "Buffers:") {
+		p.hasBuffers = true
+	}
+	p.parseDetail(current, content)
+	return nil
+}
+
+func (p *textParser) addNode(indent int, content string) error {
+	match := nodeLineRegex.FindStringSubmatch(content)
+	if match == nil || match[1] == "" {
+		return fmt.Errorf("unable to parse plan node line: %s", content)
+	}
+
+	plan := map[string]interface{}{}
+	parseNodeName(match[1], plan)
+	plan["Parallel Aware"] = plan["Parallel Aware"] == true
+
+	if match[2] != "" {
+		plan["Startup Cost"] = parseTextNumber(match[2])
+		plan["Total Cost"] = parseTextNumber(match[3])
+		plan["Plan Rows"] = parseTextNumber(match[4])
+		plan["Plan Width"] = parseTextNumber(match[5])
+	}
+
+	if match[10] != "" {
+		plan["Actual Startup Time"] = 0.0
+		plan["Actual Total Time"] = 0.0
+		plan["Actual Rows"] = 0.0
+		plan["Actual Loops"] = 0.0
+	} else if match[8] != "" {
+		plan["Actual Startup Time"] = parseTextNumber(match[6])
+		plan["Actual Total Time"] = parseTextNumber(match[7])
+		plan["Actual Rows"] = parseTextNumber(match[8])
+		plan["Actual Loops"] = parseTextNumber(match[9])
+	}
+
+	if p.pendingLabel != "" {
+		if strings.HasPrefix(p.pendingLabel, "SubPlan") {
+			plan["Parent Relationship"] = "SubPlan"
+		} else {
+			plan["Parent Relationship"] = "InitPlan"
+		}
+		plan["Subplan Name"] = p.pendingLabel
+		p.pendingLabel = ""
+	}
+	p.block = nil
+
+	if p.root == nil {
+		p.root = plan
+		p.stack = []textNode{{indent: indent, plan: plan}}
+		return nil
+	}
+
+	for len(p.stack) > 1 && p.stack[len(p.stack)-1].indent >= indent {
+		p.stack = p.stack[:len(p.stack)-1]
+	}
+	parent := p.stack[len(p.stack)-1].plan
+	children, _ := parent["Plans"].([]interface{})
+	parent["Plans"] = append(children, plan)
+	p.stack = append(p.stack, textNode{indent: indent, plan: plan})
+
+	return nil
+}
+
+// parseQueryLine handles the lines following the plan tree which describe the
+// query as a whole.
+func (p *textParser) parseQueryLine(indent int, content string) error {
+	key, value, found := strings.Cut(content, ":")
+	if !found {
+		return fmt.Errorf("unable to parse explain line: %s", content)
+	}
+	value = strings.TrimSpace(value)
+
+	switch {
+	case key == "Planning Time" || key == "Execution Time":
+		p.planObject[key] = parseTextNumber(strings.TrimSuffix(value, " ms"))
+	case key == "Planning" || key == "JIT":
+		block := map[string]interface{}{}
+		p.planObject[key] = block
+		p.block = block
+		p.blockIndent = indent
+	case key == "Settings":
+		p.planObject[key] = parseTextSettings(value)
+	case strings.HasPrefix(key, "Trigger "):
+		triggers, _ := p.planObject["Triggers"].([]interface{})
+		p.planObject["Triggers"] = append(triggers, parseTextTrigger(key, value))
+	default:
+		p.planObject[key] = parseTextValue(value)
+	}
+	return nil
+}
+
+func (p *textParser) parseDetail(plan map[string]interface{}, content string) {
+	key, value, found := strings.Cut(content, ": ")
+	if !found {
+		key, value = strings.TrimSuffix(content, ":"), ""
+	}
+	value = strings.TrimSpace(value)
+
+	switch key {
+	case "Buffers":
+		parseTextBuffers(plan, value)
+	case "I/O Timings":
+		parseTextIOTimings(plan, value)
+	case "WAL":
+		parseTextKeyValues(plan, value, map[string]string{
+			"records": "WAL Records",
+			"fpi":     "WAL FPI",
+			"bytes":   "WAL Bytes",
+		})
+	case "Heap Blocks":
+		parseTextKeyValues(plan, value, map[string]string{
+			"exact": "Exact Heap Blocks",
+			"lossy": "Lossy Heap Blocks",
+		})
+	case "Sort Method":
+		parseTextSortMethod(plan, value)
+	case "Buckets":
+		parseTextHashDetail(plan, content)
+	case "Hits":
+		parseTextSpacedPairs(plan, content, map[string]string{
+			"Hits":         "Cache Hits",
+			"Misses":       "Cache Misses",
+			"Evictions":    "Cache Evictions",
+			"Overflows":    "Cache Overflows",
+			"Memory Usage": "Peak Memory Usage",
+		})
+	case "Functions":
+		plan[key] = parseTextNumber(value)
+	case "Options", "Timing":
+		plan[key] = parseTextJitBlock(value)
+	case "Sort Key", "Group Key", "Presorted Key", "Output", "Hash Key", "Cache Key":
+		list := make([]interface{}, 0)
+		for _, item := range splitTextList(value) {
+			list = append(list, item)
+		}
+		plan[key] = list
+	default:
+		plan[key] = parseTextValue(value)
+	}
+}
+
+func findWorker(plan map[string]interface{}, number string) map[string]interface{} {
+	workerNumber := parseTextNumber(number)
+	workers, _ := plan["Workers"].([]interface{})
+	for _, w := range workers {
+		worker := w.(map[string]interface{})
+		if worker["Worker Number"] == workerNumber {
+			return worker
+		}
+	}
+	worker := map[string]interface{}{"Worker Number": workerNumber}
+	plan["Workers"] = append(workers, worker)
+	return worker
+}
+
+var aggregateStrategies = map[string]string{
+	"Aggregate":      "Plain",
+	"HashAggregate":  "Hashed",
+	"GroupAggregate": "Sorted",
+	"MixedAggregate": "Mixed",
+}
+
+var joinTypeRegex = regexp.MustCompile(`^(Hash|Merge)(?: (Left|Right|Full|Semi|Anti|Right Semi|Right Anti))? Join$`)
+var nestedLoopRegex = regexp.MustCompile(`^Nested Loop(?: (Left|Right|Full|Semi|Anti|Right Semi|Right Anti) Join)?$`)
+var usingRegex = regexp.MustCompile(`^(.*?)( Backward)? using (\S+) on (\S+)(?: (\S+))?$`)
+var onRegex = regexp.MustCompile(`^(.*?) on (\S+)(?: (\S+))?$`)
+var modifyRegex = regexp.MustCompile(`^(Insert|Update|Delete|Merge)$`)
+
+// parseNodeName reverses the node descriptions built by explain.c, e.g.
+// "Parallel Index Scan Backward using idx on orders o" into the separate
+// attributes reported by the structured formats.
+func parseNodeName(name string, plan map[string]interface{}) {
+	if rest, ok := strings.CutPrefix(name, "Parallel "); ok {
+		plan["Parallel Aware"] = true
+		name = rest
+	}
+	if rest, ok := strings.CutPrefix(name, "Async "); ok {
+		plan["Async Capable"] = true
+		name = rest
+	}
+	for _, mode := range []string{"Partial", "Finalize"} {
+		if rest, ok := strings.CutPrefix(name, mode+" "); ok {
+			plan["Partial Mode"] = mode
+			name = rest
+		}
+	}
+
+	if match := usingRegex.FindStringSubmatch(name); match != nil {
+		plan["Node Type"] = match[1]
+		if match[2] != "" {
+			plan["Scan Direction"] = "Backward"
+		} else {
+			plan["Scan Direction"] = "Forward"
+		}
+		plan["Index Name"] = unquoteIdentifier(match[3])
+		setRelation(plan, match[4], match[5])
+		return
+	}
+
+	if match := onRegex.FindStringSubmatch(name); match != nil {
+		nodeType := match[1]
+		target := unquoteIdentifier(match[2])
+		switch {
+		case modifyRegex.MatchString(nodeType):
+			plan["Node Type"] = "ModifyTable"
+			plan["Operation"] = nodeType
+			setRelation(plan, match[2], match[3])
+		case nodeType == "Bitmap Index Scan":
+			plan["Node Type"] = nodeType
+			plan["Index Name"] = target
+		case nodeType == "CTE Scan" || nodeType == "WorkTable Scan":
+			plan["Node Type"] = nodeType
+			plan["CTE Name"] = target
+			setAlias(plan, match[3], target)
+		case nodeType == "Function Scan":
+			plan["Node Type"] = nodeType
+			plan["Function Name"] = target
+			setAlias(plan, match[3], target)
+		case nodeType == "Table Function Scan":
+			plan["Node Type"] = nodeType
+			plan["Table Function Name"] = target
+			setAlias(plan, match[3], target)
+		case nodeType == "Named Tuplestore Scan":
+			plan["Node Type"] = nodeType
+			plan["Tuplestore Name"] = target
+			setAlias(plan, match[3], target)
+		case nodeType == "Subquery Scan" || nodeType == "Values Scan":
+			plan["Node Type"] = nodeType
+			plan["Alias"] = target
+		default:
+			plan["Node Type"] = nodeType
+			setRelation(plan, match[2], match[3])
+		}
+		return
+	}
+
+	if strategy, ok := aggregateStrategies[name]; ok {
+		plan["Node Type"] = "Aggregate"
+		plan["Strategy"] = strategy
+		return
+	}
+
+	if command, ok := strings.CutPrefix(name, "HashSetOp "); ok {
+		plan["Node Type"] = "SetOp"
+		plan["Strategy"] = "Hashed"
+		plan["Command"] = command
+		return
+	}
+
+	if command, ok := strings.CutPrefix(name, "SetOp "); ok {
+		plan["Node Type"] = "SetOp"
+		plan["Strategy"] = "Sorted"
+		plan["Command"] = command
+		return
+	}
+
+	if match := joinTypeRegex.FindStringSubmatch(name); match != nil {
+		plan["Node Type"] = match[1] + " Join"
+		setJoinType(plan, match[2])
+		return
+	}
+
+	if match := nestedLoopRegex.FindStringSubmatch(name); match != nil {
+		plan["Node Type"] = "Nested Loop"
+		setJoinType(plan, match[1])
+		return
+	}
+
+	plan["Node Type"] = name
+}
+
+func setJoinType(plan map[string]interface{}, joinType string) {
+	if joinType != "" {
+		plan["Join Type"] = joinType
+	} else {
+		plan["Join Type"] = "Inner"
+	}
+}
+
+func setRelation(plan map[string]interface{}, relation string, alias string) {
+	schema, relationName, found := strings.Cut(relation, ".")
+	if found && !strings.HasPrefix(relation, `"`) {
+		plan["Schema"] = unquoteIdentifier(schema)
+	} else {
+		relationName = relation
+	}
+	relationName = unquoteIdentifier(relationName)
+	plan["Relation Name"] = relationName
+	setAlias(plan, alias, relationName)
+}
+
+func setAlias(plan map[string]interface{}, alias string, defaultAlias string) {
+	if alias != "" {
+		plan["Alias"] = unquoteIdentifier(alias)
+	} else {
+		plan["Alias"] = defaultAlias
+	}
+}
+
+func unquoteIdentifier(identifier string) string {
+	if len(identifier) >= 2 && strings.HasPrefix(identifier, `"`) && strings.HasSuffix(identifier, `"`) {
+		return strings.ReplaceAll(identifier[1:len(identifier)-1], `""`, `"`)
+	}
+	return identifier
+}
+
+// parseTextBuffers parses "shared hit=1 read=2, local dirtied=3, temp written=4"
+func parseTextBuffers(plan map[string]interface{}, value string) {
+	for _, segment := range strings.Split(value, ",") {
+		fields := strings.Fields(segment)
+		if len(fields) == 0 {
+			continue
+		}
+		prefix := capitalize(fields[0])
+		for _, field := range fields[1:] {
+			name, amount, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+			key := fmt.Sprintf("%s %s Blocks", prefix, capitalize(name))
+			plan[key] = parseTextNumber(amount)
+		}
+	}
+}
+
+// parseTextIOTimings handles the pre PG16 "read=1.2 write=0.3" and
+// "shared/local read=1.2, temp read=0.1" and the later
+// "shared read=1.2 write=0.3, temp read=0.1" forms.
+func parseTextIOTimings(plan map[string]interface{}, value string) {
+	for _, segment := range strings.Split(value, ",") {
+		fields := strings.Fields(segment)
+		prefix := "I/O"
+		if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+			// PG15 reports "shared/local" timings under the pre PG16 keys.
+			if fields[0] != "shared/local" {
+				prefix = capitalize(fields[0]) + " I/O"
+			}
+			fields = fields[1:]
+		}
+		for _, field := range fields {
+			name, amount, found := strings.Cut(field, "=")
+			if !found {
+				continue
+			}
+			key := fmt.Sprintf("%s %s Time", prefix, capitalize(name))
+			plan[key] = parseTextNumber(amount)
+		}
+	}
+}
+
+func parseTextKeyValues(plan map[string]interface{}, value string, keys map[string]string) {
+	for _, field := range strings.Fields(value) {
+		name, amount, found := strings.Cut(field, "=")
+		if key, ok := keys[name]; found && ok {
+			plan[key] = parseTextNumber(amount)
+		}
+	}
+}
+
+// parseTextSpacedPairs parses lines that pack several "Key: value" pairs
+// separated by two spaces, e.g. "Hits: 10  Misses: 2  Memory Usage: 1kB".
+func parseTextSpacedPairs(plan map[string]interface{}, content string, keys map[string]string) {
+	for _, pair := range strings.Split(content, "  ") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), ": ")
+		if key, ok := keys[name]; found && ok {
+			plan[key] = parseTextNumber(strings.TrimSuffix(value, "kB"))
+		}
+	}
+}
+
+// parseTextSortMethod parses "quicksort  Memory: 25kB" or
+// "external merge  Disk: 1024kB".
+func parseTextSortMethod(plan map[string]interface{}, value string) {
+	method, space, _ := strings.Cut(value, "  ")
+	plan["Sort Method"] = strings.TrimSpace(method)
+	spaceType, amount, found := strings.Cut(strings.TrimSpace(space), ": ")
+	if found {
+		plan["Sort Space Type"] = spaceType
+		plan["Sort Space Used"] = parseTextNumber(strings.TrimSuffix(amount, "kB"))
+	}
+}
+
+var originallyRegex = regexp.MustCompile(`^(\d+) \(originally (\d+)\)$`)
+
+// parseTextHashDetail parses
+// "Buckets: 2048 (originally 1024)  Batches: 2 (originally 1)  Memory Usage: 9kB"
+func parseTextHashDetail(plan map[string]interface{}, content string) {
+	for _, pair := range strings.Split(content, "  ") {
+		name, value, found := strings.Cut(strings.TrimSpace(pair), ": ")
+		if !found {
+			continue
+		}
+		var key string
+		switch name {
+		case "Buckets":
+			key = "Hash Buckets"
+		case "Batches":
+			key = "Hash Batches"
+		case "Memory Usage":
+			plan["Peak Memory Usage"] = parseTextNumber(strings.TrimSuffix(value, "kB"))
+			continue
+		default:
+			continue
+		}
+		if match := originallyRegex.FindStringSubmatch(value); match != nil {
+			plan[key] = parseTextNumber(match[1])
+			plan["Original "+key] = parseTextNumber(match[2])
+		} else {
+			plan[key] = parseTextNumber(value)
+			plan["Original "+key] = parseTextNumber(value)
+		}
+	}
+}
+
+var jitDetailRegex = regexp.MustCompile(`\s*\([^)]*\)`)
+
+// parseTextJitBlock parses "Inlining false, Optimization false" and
+// "Generation 0.512 ms, Inlining 0.000 ms, Total 5.1 ms"
+func parseTextJitBlock(value string) map[string]interface{} {
+	result := map[string]interface{}{}
+	value = jitDetailRegex.ReplaceAllString(value, "")
+	for _, segment := range strings.Split(value, ",") {
+		segment = strings.TrimSuffix(strings.TrimSpace(segment), " ms")
+		index := strings.LastIndex(segment, " ")
+		if index < 0 {
+			continue
+		}
+		result[segment[:index]] = parseTextValue(segment[index+1:])
+	}
+	return result
+}
+
+var triggerRegex = regexp.MustCompile(`^Trigger (.*?)(?: on (\S+))?$`)
+var triggerValueRegex = regexp.MustCompile(`time=([\d.]+) calls=(\d+)`)
+
+// parseTextTrigger parses "Trigger name on relation: time=1.2 calls=4"
+func parseTextTrigger(key string, value string) map[string]interface{} {
+	trigger := map[string]interface{}{}
+	if match := triggerRegex.FindStringSubmatch(key); match != nil {
+		name := match[1]
+		if constraint, ok := strings.CutPrefix(name, "for constraint "); ok {
+			trigger["Constraint Name"] = constraint
+		} else {
+			trigger["Trigger Name"] = name
+		}
+		if match[2] != "" {
+			trigger["Relation"] = match[2]
+		}
+	}
+	if match := triggerValueRegex.FindStringSubmatch(value); match != nil {
+		trigger["Time"] = parseTextNumber(match[1])
+		trigger["Calls"] = parseTextNumber(match[2])
+	}
+	return trigger
+}
+
+// parseTextSettings parses "work_mem = '64MB', random_page_cost = '1.1'"
+func parseTextSettings(value string) map[string]interface{} {
+	settings := map[string]interface{}{}
+	for _, segment := range splitTextList(value) {
+		name, setting, found := strings.Cut(segment, " = ")
+		if found {
+			settings[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(setting), "'")
+		}
+	}
+	return settings
+}
+
+// splitTextList splits a comma separated list on the commas that are not
+// nested inside parentheses or quotes.
+func splitTextList(value string) []string {
+	result := make([]string, 0)
+	depth := 0
+	inQuote := false
+	start := 0
+	for i, r := range value {
+		switch {
+		case r == '\'':
+			inQuote = !inQuote
+		case inQuote:
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case r == ',' && depth == 0:
+			result = append(result, strings.TrimSpace(value[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(value[start:]); last != "" {
+		result = append(result, last)
+	}
+	return result
+}
+
+func parseTextValue(value string) interface{} {
+	if value == "true" || value == "false" {
+		return value == "true"
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		return number
+	}
+	return value
+}
+
+func parseTextNumber(value string) float64 {
+	number, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	return number
+}
+
+var bufferKeys = []string{
+	"Shared Hit Blocks", "Shared Read Blocks", "Shared Dirtied Blocks", "Shared Written Blocks",
+	"Local Hit Blocks", "Local Read Blocks", "Local Dirtied Blocks", "Local Written Blocks",
+	"Temp Read Blocks", "Temp Written Blocks",
+}
+
+// fillBufferDefaults sets the buffer counts the text format omits when they
+// are zero, on the analyzed nodes which are the ones reporting buffers.
+func fillBufferDefaults(plan map[string]interface{}) {
+	if _, analyzed := plan["Actual Loops"]; analyzed {
+		for _, key := range bufferKeys {
+			if _, ok := plan[key]; !ok {
+				plan[key] = 0.0
+			}
+		}
+	}
+	children, _ := plan["Plans"].([]interface{})
+	for _, child := range children {
+		fillBufferDefaults(child.(map[string]interface{}))
+	}
+}
+
+var memberParents = []string{"Append", "Merge Append", "BitmapAnd", "BitmapOr"}
+
+// inferParentRelationships fills in the relationship the text format leaves
+// implicit through child order.
+func inferParentRelationships(plan map[string]interface{}) {
+	children, _ := plan["Plans"].([]interface{})
+	nodeType, _ := plan["Node Type"].(string)
+	position := 0
+	for _, c := range children {
+		child := c.(map[string]interface{})
+		if _, ok := child["Parent Relationship"]; !ok {
+			switch {
+			case slices.Contains(memberParents, nodeType):
+				child["Parent Relationship"] = "Member"
+			case nodeType == "Subquery Scan":
+				child["Parent Relationship"] = "Subquery"
+			case position == 0:
+				child["Parent Relationship"] = "Outer"
+			default:
+				child["Parent Relationship"] = "Inner"
+			}
+			position++
+		}
+		inferParentRelationships(child)
+	}
+}
+
+func capitalize(value string) string {
+	if value == "" {
+		return value
+	}
+	return strings.ToUpper(value[:1]) + value[1:]
+}
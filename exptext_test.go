@@ -0,0 +1,313 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertTextNoBuffers(t *testing.T) {
+	data, err := os.ReadFile("./testdata/analyze_no_buffers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 69.662, plan.executionTime)
+	assert.True(t, plan.analyzed)
+	assert.Equal(t, 4, len(plan.nodes))
+	assert.Equal(t, "Finalize Aggregate", plan.nodes[0].Name())
+	assert.Equal(t, 3, plan.nodes[1].PlannedWorkers)
+	assert.Equal(t, "Index Only Scan", plan.nodes[3].NodeType)
+	assert.Equal(t, true, plan.nodes[3].ParallelAware)
+	assert.Equal(t, "dm_plays_type_index", plan.nodes[3].IndexName)
+	assert.Equal(t, "dm_plays", plan.nodes[3].RelationName)
+	assert.Equal(t, 1036113, plan.nodes[3].Analyzed.ActualRows)
+	assert.Equal(t, Position{Id: 4, Level: 4, Parent: 3, Display: true, BelowGather: true}, plan.nodes[3].Position)
+}
+
+func TestConvertTextMatchesJson(t *testing.T) {
+	textData, err := os.ReadFile("./testdata/analyze_no_buffers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/analyze_no_buffers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	textPlan, err := Convert(string(textData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan, textPlan)
+}
+
+func TestConvertTextDetails(t *testing.T) {
+	data, err := os.ReadFile("./testdata/analyze_buffers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1.25, plan.executionTime)
+
+	root := plan.nodes[0]
+	assert.Equal(t, "Hash Join", root.NodeType)
+	assert.Equal(t, "Right Semi", root.JoinType)
+	assert.Equal(t, "(o.customer_id = c.id)", root.HashCond)
+	assert.Equal(t, 14, root.Analyzed.SharedBuffersHit)
+	assert.Equal(t, 3, root.Analyzed.SharedBuffersRead)
+	assert.Equal(t, 2, root.Analyzed.TempWriteBlocks)
+
+	cte := plan.nodes[1]
+	assert.Equal(t, "CTE recent", cte.SubPlanName)
+	assert.Equal(t, "InitPlan", cte.ParentRelationship)
+	assert.Equal(t, "orders", cte.RelationName)
+	assert.Equal(t, "(created_at > (now() - '7 days'::interval))", cte.Filter)
+
+	sort := plan.nodes[2]
+	assert.Equal(t, "Outer", sort.ParentRelationship)
+	assert.Equal(t, []string{"o.customer_id", "(lower(o.note)) DESC"}, sort.SortKeys)
+	assert.Equal(t, 1, sort.Position.Parent)
+
+	indexScan := plan.nodes[3]
+	assert.Equal(t, "Index Scan", indexScan.NodeType)
+	assert.Equal(t, "orders_customer_idx", indexScan.IndexName)
+	assert.Equal(t, "(customer_id < 100)", indexScan.IndexCond)
+	assert.Equal(t, 3, indexScan.Position.Parent)
+	assert.Equal(t, 0, indexScan.Analyzed.TempReadBlocks)
+
+	hash := plan.nodes[4]
+	assert.Equal(t, "Hash", hash.NodeType)
+	assert.Equal(t, "Inner", hash.ParentRelationship)
+	assert.Equal(t, 1, hash.Position.Parent)
+
+	cteScan := plan.nodes[5]
+	assert.Equal(t, "recent", cteScan.CteName)
+	assert.Equal(t, 0, cteScan.Analyzed.ActualLoops)
+
+	subPlan := plan.nodes[6]
+	assert.Equal(t, "SubPlan 1", subPlan.SubPlanName)
+	assert.Equal(t, "SubPlan", subPlan.ParentRelationship)
+	assert.Equal(t, 480, subPlan.Analyzed.ActualLoops)
+	assert.Equal(t, 1, subPlan.Position.Parent)
+}
+
+func TestConvertTextRejectsNonPlans(t *testing.T) {
+	for _, input := range []string{
+		"hello world",
+		"SELECT * FROM t;",
+		"ERROR:  relation \"t\" does not exist\nLINE 1: explain select * from t;",
+	} {
+		_, err := Convert(input)
+		assert.ErrorContains(t, err, "no plan node found", input)
+	}
+
+	plan, err := Convert("Result  (actual time=0.001..0.001 rows=1 loops=1)")
+	assert.NoError(t, err)
+	assert.Equal(t, "Result", plan.nodes[0].NodeType)
+}
+
+func TestConvertTextPlanningBuffers(t *testing.T) {
+	text := strings.Join([]string{
+		"Seq Scan on orders  (cost=0.00..35.50 rows=2550 width=4)",
+		"Planning:",
+		"  Buffers: shared hit=3",
+		"Planning Time: 0.080 ms",
+	}, "\n")
+	planObject, err := decodeText(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 3.0, planObject["Planning"].(map[string]interface{})["Shared Hit Blocks"])
+	assert.NotContains(t, planObject["Plan"], "Shared Hit Blocks")
+}
+
+func TestDetectFormat(t *testing.T) {
+	assert.Equal(t, FORMAT_JSON, DetectFormat(" [\n  {\"Plan\": {}}]"))
+	assert.Equal(t, FORMAT_TEXT, DetectFormat(" Seq Scan on t  (cost=0.00..1.00 rows=1 width=4)"))
+}
+
+func TestParseNodeName(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		name   string
+		result map[string]interface{}
+	}{
+		{
+			desc:   "Hash aggregate",
+			name:   "Partial HashAggregate",
+			result: map[string]interface{}{"Node Type": "Aggregate", "Strategy": "Hashed", "Partial Mode": "Partial"},
+		},
+		{
+			desc:   "Hashed setop",
+			name:   "HashSetOp Except",
+			result: map[string]interface{}{"Node Type": "SetOp", "Strategy": "Hashed", "Command": "Except"},
+		},
+		{
+			desc:   "Modify table",
+			name:   "Update on public.orders o",
+			result: map[string]interface{}{"Node Type": "ModifyTable", "Operation": "Update", "Schema": "public", "Relation Name": "orders", "Alias": "o"},
+		},
+		{
+			desc:   "Nested loop with join type",
+			name:   "Nested Loop Left Join",
+			result: map[string]interface{}{"Node Type": "Nested Loop", "Join Type": "Left"},
+		},
+		{
+			desc:   "Hash is not a join",
+			name:   "Hash",
+			result: map[string]interface{}{"Node Type": "Hash"},
+		},
+		{
+			desc:   "Function scan",
+			name:   "Function Scan on generate_series g",
+			result: map[string]interface{}{"Node Type": "Function Scan", "Function Name": "generate_series", "Alias": "g"},
+		},
+		{
+			desc:   "Bitmap index scan",
+			name:   "Bitmap Index Scan on orders_pkey",
+			result: map[string]interface{}{"Node Type": "Bitmap Index Scan", "Index Name": "orders_pkey"},
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			plan := map[string]interface{}{}
+			parseNodeName(tC.name, plan)
+			assert.Equal(t, tC.result, plan)
+		})
+	}
+}
+
+func TestConvertTextSortAndHashDetails(t *testing.T) {
+	data, err := os.ReadFile("./testdata/analyze_buffers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 150, plan.nodes[1].Analyzed.RowsRemovedByFilter)
+	assert.Equal(t, "quicksort", plan.nodes[2].Analyzed.SortMethod)
+	assert.Equal(t, "Memory", plan.nodes[2].Analyzed.SortSpaceType)
+	assert.Equal(t, 25, plan.nodes[2].Analyzed.SortSpaceUsed)
+	assert.Equal(t, 1024, plan.nodes[4].Analyzed.HashBuckets)
+	assert.Equal(t, 1, plan.nodes[4].Analyzed.HashBatches)
+	assert.Equal(t, 30, plan.nodes[4].Analyzed.PeakMemoryUsage)
+}
+
+func TestConvertTextQueryAttributes(t *testing.T) {
+	data, err := os.ReadFile("./testdata/analyze_buffers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 0.12, plan.planningTime)
+	assert.Equal(t, 3, plan.planningBuffersHit)
+	assert.Equal(t, []Trigger{{Name: "orders_audit", Time: 0.512, Calls: 480}}, plan.triggers)
+	assert.Equal(t, 4, plan.jit.Functions)
+	assert.Equal(t, false, plan.jit.Options["Inlining"])
+	assert.Equal(t, 0.512, plan.jit.Timing["Generation"])
+	assert.Equal(t, 4.912, plan.jit.Timing["Total"])
+}
+
+func TestConvertTextWorkers(t *testing.T) {
+	textData, err := os.ReadFile("./testdata/parallel_workers.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/parallel_workers.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	textPlan, err := Convert(string(textData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan.nodes[1].Analyzed.Workers, textPlan.nodes[1].Analyzed.Workers)
+	assert.Equal(t, jsonPlan.settings, textPlan.settings)
+}
+
+func TestConvertTextIOTimings(t *testing.T) {
+	textData, err := os.ReadFile("./testdata/io_timings.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/io_timings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	textPlan, err := Convert(string(textData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan.nodes[0].Analyzed, textPlan.nodes[0].Analyzed)
+	assert.Equal(t, jsonPlan.nodes[1].Analyzed, textPlan.nodes[1].Analyzed)
+
+	plan := map[string]interface{}{}
+	parseTextIOTimings(plan, "shared/local read=2.500 write=0.500, temp read=1.000")
+	assert.Equal(t, map[string]interface{}{"I/O Read Time": 2.5, "I/O Write Time": 0.5, "Temp I/O Read Time": 1.0}, plan)
+}
+
+func TestConvertTextWAL(t *testing.T) {
+	textData, err := os.ReadFile("./testdata/wal.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/wal.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	textPlan, err := Convert(string(textData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan.nodes[0].Analyzed, textPlan.nodes[0].Analyzed)
+	assert.Equal(t, jsonPlan.nodes[1].Analyzed, textPlan.nodes[1].Analyzed)
+}
+
+func TestConvertTextVerbose(t *testing.T) {
+	textData, err := os.ReadFile("./testdata/verbose_selfjoin.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := os.ReadFile("./testdata/verbose_selfjoin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	textPlan, err := Convert(string(textData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonPlan, err := Convert(string(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, jsonPlan, textPlan)
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Attributes containing a hyphen or a slash, both written as a hyphen in XML,
+// in their name that can't be recovered by replacing the hyphens of the XML
+// element name with spaces.
+var xmlNameExceptions = map[string]string{
+	"Full-sort-Groups":      "Full-sort Groups",
+	"Pre-sorted-Groups":     "Pre-sorted Groups",
+	"I-O-Read-Time":         "I/O Read Time",
+	"I-O-Write-Time":        "I/O Write Time",
+	"Shared-I-O-Read-Time":  "Shared I/O Read Time",
+	"Shared-I-O-Write-Time": "Shared I/O Write Time",
+	"Local-I-O-Read-Time":   "Local I/O Read Time",
+	"Local-I-O-Write-Time":  "Local I/O Write Time",
+	"Temp-I-O-Read-Time":    "Temp I/O Read Time",
+	"Temp-I-O-Write-Time":   "Temp I/O Write Time",
+}
+
+// Elements with children of these names are collected into a list rather
+// than a map, as are the listContainers.
+var xmlListItems = []string{"Item", "Group-Set"}
+
+// Attributes that are lists even when they are empty.
+var listContainers = []string{"Plans", "Workers", "Triggers"}
+
+type xmlElement struct {
+	name     string
+	text     strings.Builder
+	children []*xmlElement
+}
+
+// decodeXml parses the XML format of EXPLAIN into the same shape that the
+// JSON format decodes to.
+func decodeXml(data string) (map[string]interface{}, error) {
+	decoder := xml.NewDecoder(strings.NewReader(data))
+
+	root := &xmlElement{}
+	stack := []*xmlElement{root}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			element := &xmlElement{name: t.Name.Local}
+			parent := stack[len(stack)-1]
+			parent.children = append(parent.children, element)
+			stack = append(stack, element)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			stack[len(stack)-1].text.Write(t)
+		}
+	}
+
+	if len(root.children) != 1 || root.children[0].name != "explain" {
+		return nil, errors.New("expected an <explain> element in xml explain")
+	}
+
+	queries := root.children[0].children
+	if len(queries) != 1 || queries[0].name != "Query" {
+		return nil, errors.New("expected a single <Query> element in xml explain")
+	}
+
+	planObject, ok := xmlValue(queries[0]).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("unexpected value in xml explain, expected <Query> to contain elements")
+	}
+
+	return planObject, nil
+}
+
+func xmlValue(element *xmlElement) interface{} {
+	if len(element.children) == 0 {
+		if slices.Contains(listContainers, xmlAttributeName(element.name)) {
+			return []interface{}{}
+		}
+		return parseTextValue(strings.TrimSpace(element.text.String()))
+	}
+
+	if slices.Contains(xmlListItems, element.children[0].name) || slices.Contains(listContainers, xmlAttributeName(element.name)) {
+		list := make([]interface{}, 0, len(element.children))
+		for _, child := range element.children {
+			// Items are expressions, e.g. the 1 output by select 1, which are
+			// strings like in the JSON format.
+			if child.name == "Item" && len(child.children) == 0 {
+				list = append(list, strings.TrimSpace(child.text.String()))
+				continue
+			}
+			list = append(list, xmlValue(child))
+		}
+		return list
+	}
+
+	result := make(map[string]interface{}, len(element.children))
+	for _, child := range element.children {
+		result[xmlAttributeName(child.name)] = xmlValue(child)
+	}
+	return result
+}
+
+func xmlAttributeName(name string) string {
+	if exception, ok := xmlNameExceptions[name]; ok {
+		return exception
+	}
+	return strings.ReplaceAll(name, "-", " ")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// decodeYaml parses the YAML format of EXPLAIN into the same shape that the
+// JSON format decodes to.
+func decodeYaml(data string) (map[string]interface{}, error) {
+	var decoded []interface{}
+
+	if err := yaml.Unmarshal([]byte(data), &decoded); err != nil {
+		return nil, err
+	}
+
+	if len(decoded) != 1 {
+		return nil, fmt.Errorf("expected a single query in yaml explain, found %d", len(decoded))
+	}
+
+	planObject, ok := normalizeYaml(decoded[0]).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected value in yaml explain, expected mapping: %v", decoded[0])
+	}
+
+	return planObject, nil
+}
+
+// normalizeYaml converts the integers yaml produces into the float64 values
+// encoding/json would have produced and turns empty values like "Triggers: "
+// into empty lists.
+func normalizeYaml(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if item == nil && slices.Contains(listContainers, key) {
+				v[key] = []interface{}{}
+			} else {
+				v[key] = normalizeYaml(item)
+			}
+		}
+		return v
+	case []interface{}:
+		for i, item := range v {
+			v[i] = normalizeYaml(item)
+		}
+		return v
+	case int:
+		return float64(v)
+	}
+	return value
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// The icicle chart draws the plan from the root down, each node as wide as the
+// time spent in it and its descendants and colored by the time spent in the
+// node itself.
+
+// Colors from the least to the most self time.
+var icicleHeatColors = []string{"#fff3c4", "#ffe08a", "#ffc04d", "#ff9a33", "#ff7026", "#f0451f", "#d1261a"}
+
+// icicleFrame is the extent of a node as fractions of the width of the chart.
+type icicleFrame struct {
+	node  PlanNode
+	depth int
+	start float64
+	end   float64
+}
+
+// inclusiveTimes sums the self time of each node and its descendants, unlike
+// the actual total time this adds up across loops, workers and CTEs so that
+// the children of a node always fit within it.
+func inclusiveTimes(nodes []PlanNode) []float64 {
+	inclusive := make([]float64, len(nodes))
+	// Children follow their parent, so they are summed before it.
+	for i := len(nodes) - 1; i >= 0; i-- {
+		inclusive[i] += max(0, nodes[i].Analyzed.ExclusiveTime)
+		if parent := nodes[i].Position.Parent; parent > 0 {
+			inclusive[parent-1] += inclusive[i]
+		}
+	}
+	return inclusive
+}
+
+// icicleLayout places the nodes, which are in tree order, under their parent
+// in proportion to their inclusive time. A node whose parent isn't among the
+// nodes spans the whole chart.
+func icicleLayout(nodes []PlanNode, inclusive []float64, parentOf func(node PlanNode) int) []icicleFrame {
+	childTime := map[int]float64{}
+	for _, node := range nodes {
+		childTime[parentOf(node)] += inclusive[node.Position.Id-1]
+	}
+
+	frames := make([]icicleFrame, 0, len(nodes))
+	placed := map[int]int{}
+	nextStart := map[int]float64{}
+	for _, node := range nodes {
+		id := node.Position.Id
+		frame := icicleFrame{node: node, start: 0, end: 1}
+		parent := parentOf(node)
+		if p, ok := placed[parent]; ok {
+			parentFrame := frames[p]
+			// Children can't be wider than their parent even when the times don't
+			// add up.
+			total := max(inclusive[parent-1], childTime[parent])
+			width := 0.0
+			if total > 0 {
+				width = (parentFrame.end - parentFrame.start) * inclusive[id-1] / total
+			}
+			frame.depth = parentFrame.depth + 1
+			frame.start = nextStart[parent]
+			frame.end = frame.start + width
+			nextStart[parent] = frame.end
+		}
+		nextStart[id] = frame.start
+		placed[id] = len(frames)
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+// heatColor picks the color for the share of the highest self time.
+func heatColor(heat float64) string {
+	index := int(heat * float64(len(icicleHeatColors)))
+	return icicleHeatColors[max(0, min(index, len(icicleHeatColors)-1))]
+}
+
+func maxExclusiveTime(nodes []PlanNode) float64 {
+	maxTime := 0.0
+	for _, node := range nodes {
+		maxTime = max(maxTime, node.Analyzed.ExclusiveTime)
+	}
+	return maxTime
+}
+
+// Lines of the icicle view besides the chart and the help: the title, the
+// status line, the blank line below the chart, the selected node and the final
+// newline.
+var icicleChrome = 5
+
+// icicleView replaces the plan tree and the sections below it with the icicle
+// chart of the displayed nodes, the cursor is the same as in the tree.
+func (m Model) icicleView() string {
+	var buf strings.Builder
+
+	inclusive := inclusiveTimes(m.nodes)
+	frames := icicleLayout(m.DisplayNodes, inclusive, func(node PlanNode) int {
+		return node.ViewPosition(m.ctx).Parent
+	})
+
+	helpView := m.help.View(m.keys)
+	height := max(1, m.ctx.Height-icicleChrome-lipgloss.Height(helpView))
+
+	maxDepth := 0
+	cursorDepth := 0
+	for _, frame := range frames {
+		maxDepth = max(maxDepth, frame.depth)
+		if frame.node.Position.Id == m.ctx.SelectedNode.Position.Id {
+			cursorDepth = frame.depth
+		}
+	}
+	offset := max(0, cursorDepth-height+1)
+
+	maxTime := maxExclusiveTime(m.nodes)
+	width := m.ctx.Width
+	for depth := offset; depth < offset+height; depth++ {
+		column := 0
+		for _, frame := range frames {
+			if frame.depth != depth {
+				continue
+			}
+			start := int(math.Round(frame.start * float64(width)))
+			end := int(math.Round(frame.end * float64(width)))
+			if end-start < 1 || start < column {
+				continue
+			}
+			buf.WriteString(strings.Repeat(" ", start-column))
+
+			heat := 0.0
+			if maxTime > 0 {
+				heat = frame.node.Analyzed.ExclusiveTime / maxTime
+			}
+			style := lipgloss.NewStyle().Background(lipgloss.Color(heatColor(heat))).Foreground(lipgloss.Color("#000000"))
+			if frame.node.Position.Id == m.ctx.SelectedNode.Position.Id {
+				style = style.Reverse(true).Bold(true)
+			}
+			label := ansi.Truncate(" "+reportNodeName(frame.node), end-start, "…")
+			buf.WriteString(style.Render(fmt.Sprintf("%-*s", end-start, label)))
+			column = end
+		}
+		buf.WriteString("\n")
+	}
+
+	buf.WriteString("\n")
+	buf.WriteString(m.icicleSelectedView(inclusive))
+	buf.WriteString("\n")
+	buf.WriteString(helpView)
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+func (m Model) icicleSelectedView(inclusive []float64) string {
+	node := m.ctx.SelectedNode
+	if node.Position.Id == 0 {
+		return ""
+	}
+	total := inclusive[node.Position.Id-1]
+	percent := 0.0
+	if inclusive[0] > 0 {
+		percent = total / inclusive[0] * 100
+	}
+
+	var buf strings.Builder
+	buf.WriteString(m.ctx.NormalStyle.NodeName.Render(reportNodeName(node)))
+	buf.WriteString(m.ctx.NormalStyle.Gutter.Render("  self "))
+	buf.WriteString(m.ctx.NormalStyle.Value.Render(fmt.Sprintf("%.3fms (%.1f%%)", node.Analyzed.ExclusiveTime, node.Analyzed.ExclusivePercent)))
+	buf.WriteString(m.ctx.NormalStyle.Gutter.Render("  total "))
+	buf.WriteString(m.ctx.NormalStyle.Value.Render(fmt.Sprintf("%.3fms (%.1f%%)", total, percent)))
+	return ansi.Truncate(buf.String(), m.ctx.Width, "…")
+}
+
+// Dimensions of the SVG icicle chart in pixels.
+var (
+	svgWidth       = 1200
+	svgFrameHeight = 18
+	svgTitleHeight = 30
+	// Approximate width of a character of the 12px labels.
+	svgCharWidth = 7
+)
+
+// ExportSvg produces an icicle chart of the plan as a standalone SVG, each
+// frame has the node and its times as a tooltip.
+func ExportSvg(explainPlan ExplainPlan) (string, error) {
+	if !explainPlan.analyzed {
+		return "", fmt.Errorf("format 'svg' requires a plan explained with ANALYZE")
+	}
+
+	nodes := explainPlan.nodes
+	inclusive := inclusiveTimes(nodes)
+	frames := icicleLayout(nodes, inclusive, func(node PlanNode) int {
+		return node.Position.Parent
+	})
+
+	maxDepth := 0
+	for _, frame := range frames {
+		maxDepth = max(maxDepth, frame.depth)
+	}
+	height := svgTitleHeight + (maxDepth+1)*svgFrameHeight + 10
+	maxTime := maxExclusiveTime(nodes)
+
+	var buf strings.Builder
+	buf.WriteString(fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Verdana, sans-serif" font-size="12">`+"\n", svgWidth, height, svgWidth, height))
+	buf.WriteString(fmt.Sprintf(`<rect x="0" y="0" width="%d" height="%d" fill="#ffffff"/>`+"\n", svgWidth, height))
+	buf.WriteString(fmt.Sprintf(`<text x="%d" y="20" text-anchor="middle" font-size="16">Execution Time: %.3fms</text>`+"\n", svgWidth/2, explainPlan.executionTime))
+
+	for _, frame := range frames {
+		x := frame.start * float64(svgWidth-20)
+		width := (frame.end - frame.start) * float64(svgWidth-20)
+		if width < 0.5 {
+			continue
+		}
+		x += 10
+		y := svgTitleHeight + frame.depth*svgFrameHeight
+
+		heat := 0.0
+		if maxTime > 0 {
+			heat = frame.node.Analyzed.ExclusiveTime / maxTime
+		}
+		name := reportNodeName(frame.node)
+		title := fmt.Sprintf("%s, self %.3fms (%.1f%%), total %.3fms", name,
+			frame.node.Analyzed.ExclusiveTime, frame.node.Analyzed.ExclusivePercent, inclusive[frame.node.Position.Id-1])
+
+		buf.WriteString("<g>")
+		buf.WriteString(fmt.Sprintf("<title>%s</title>", html.EscapeString(title)))
+		buf.WriteString(fmt.Sprintf(`<rect x="%.1f" y="%d" width="%.1f" height="%d" rx="2" fill="%s" stroke="#ffffff"/>`,
+			x, y, width, svgFrameHeight-1, heatColor(heat)))
+		if chars := int(width-6) / svgCharWidth; chars >= 3 {
+			buf.WriteString(fmt.Sprintf(`<text x="%.1f" y="%d">%s</text>`, x+3, y+13, html.EscapeString(ansi.Truncate(name, chars, ".."))))
+		}
+		buf.WriteString("</g>\n")
+	}
+
+	buf.WriteString("</svg>\n")
+	return buf.String(), nil
+}
+
+// ExportFolded produces the folded stacks of the plan, one line per node with
+// the path from the root and the self time in microseconds, for flamegraph.pl
+// and the tools reading the same format.
+func ExportFolded(explainPlan ExplainPlan) (string, error) {
+	if !explainPlan.analyzed {
+		return "", fmt.Errorf("format 'folded' requires a plan explained with ANALYZE")
+	}
+
+	nodes := explainPlan.nodes
+	stacks := make([]string, len(nodes))
+	var buf strings.Builder
+	for i, node := range nodes {
+		frame := strings.ReplaceAll(reportNodeName(node), ";", ",")
+		if parent := node.Position.Parent; parent > 0 {
+			stacks[i] = stacks[parent-1] + ";" + frame
+		} else {
+			stacks[i] = frame
+		}
+
+		micros := int64(math.Round(node.Analyzed.ExclusiveTime * 1000))
+		if micros > 0 {
+			buf.WriteString(fmt.Sprintf("%s %d\n", stacks[i], micros))
+		}
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIcicleLayout(t *testing.T) {
+	data, err := os.ReadFile("./testdata/node_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inclusive := inclusiveTimes(explainPlan.nodes)
+	assert.InDelta(t, 231.904, inclusive[0], 0.0001)
+	assert.InDelta(t, 70.443, inclusive[2], 0.0001)
+
+	frames := icicleLayout(explainPlan.nodes, inclusive, func(node PlanNode) int {
+		return node.Position.Parent
+	})
+	assert.Equal(t, 6, len(frames))
+	assert.Equal(t, 0.0, frames[0].start)
+	assert.Equal(t, 1.0, frames[0].end)
+	// The scan and the hash share the width left by the hash join itself.
+	assert.Equal(t, 2, frames[2].depth)
+	assert.Equal(t, frames[1].start, frames[2].start)
+	assert.InDelta(t, frames[2].end, frames[4].start, 0.0000001)
+	assert.InDelta(t, 70.443/231.904, frames[2].end-frames[2].start, 0.0000001)
+	assert.InDelta(t, frames[4].start, frames[5].start, 0.0000001)
+}
+
+func TestExportFolded(t *testing.T) {
+	data, err := os.ReadFile("./testdata/node_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	folded, err := ExportFolded(explainPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(folded, "\n"), "\n")
+	assert.Equal(t, "Sort 71573", lines[0])
+	assert.Equal(t, "Sort;Hash Join;Hash;Seq Scan on customers c 9818", lines[5])
+
+	svg, err := ExportSvg(explainPlan)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 6, strings.Count(svg, "<rect x=\"")-1)
+	assert.Contains(t, svg, "<title>Sort, self 71.573ms (30.2%), total 231.904ms</title>")
+
+	data, err = os.ReadFile("./testdata/verbose_selfjoin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	explainPlan, err = Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ExportFolded(explainPlan)
+	assert.EqualError(t, err, "format 'folded' requires a plan explained with ANALYZE")
+}
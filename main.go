@@ -2,21 +2,38 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
 	sprig "github.com/Masterminds/sprig/v3"
 	tea "github.com/charmbracelet/bubbletea"
 	pgx "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/spf13/cobra"
 	ini "github.com/vaughan0/go-ini"
 )
 
 const VERSION = "0.1.1-beta.3"
 
+var renderOptions struct {
+	stat    string
+	noColor bool
+	width   int
+	bars    bool
+}
+
+var exportOptions struct {
+	format string
+	output string
+}
+
 var cliOptions struct {
 	connString  string
 	host        string
@@ -30,6 +47,29 @@ var cliOptions struct {
 var ConnConfig pgx.ConnConfig
 var PGEnvvars map[string]string = make(map[string]string)
 var ConnString string
+var RunExplainOptions = DefaultExplainOptions()
+
+// Commit the changes of data-modifying statements instead of rolling them back.
+var RunCommit bool
+
+// Values bound to the $n parameters of the executed query.
+var RunParams []QueryParam
+
+// Number of the statement of the sql file to explain, the last one when 0.
+var RunStatement int
+
+// Longest a statement may run before the server cancels it, no limit when 0.
+var StatementTimeout time.Duration
+
+var execOptions struct {
+	params      []string
+	paramsFile  string
+	timeout     time.Duration
+	wal         bool
+	verbose     bool
+	genericPlan bool
+	pick        bool
+}
 
 var zeroSourcetype SourceType
 
@@ -44,6 +84,57 @@ func main() {
 				fmt.Println(err)
 				os.Exit(1)
 			}
+			if execOptions.timeout > 0 {
+				StatementTimeout = execOptions.timeout
+			}
+			// Flags take precedence over the explain options of pgex.conf.
+			if cmd.Flags().Changed("wal") {
+				RunExplainOptions.WAL = execOptions.wal
+			}
+			if cmd.Flags().Changed("verbose") {
+				RunExplainOptions.Verbose = execOptions.verbose
+			}
+			if execOptions.genericPlan {
+				RunExplainOptions.GenericPlan = true
+				RunExplainOptions.Analyze = false
+			}
+
+			statements, err := ReadStatements(args[0])
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if execOptions.pick && len(statements) > 1 {
+				RunStatement, err = PickStatement(statements, os.Stdin, os.Stdout)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			}
+			_, query, err := SelectStatement(statements, RunStatement)
+			if err != nil {
+				fmt.Printf("%s: %s\n", args[0], err)
+				os.Exit(1)
+			}
+
+			params, err := LoadRunParams(args[0], execOptions.paramsFile, execOptions.params)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			if !RunExplainOptions.GenericPlan {
+				stat, _ := os.Stdin.Stat()
+				if missing := MissingParams(query, params); len(missing) > 0 && (stat.Mode()&os.ModeCharDevice) == 0 {
+					fmt.Printf("no value for $%d, pass it with --param %d=value or in %s\n", missing[0], missing[0], ParamsSidecar(args[0]))
+					os.Exit(1)
+				}
+				params, err = PromptParams(query, params, os.Stdin, os.Stdout)
+				if err != nil {
+					fmt.Println(err)
+					os.Exit(1)
+				}
+			}
+			RunParams = params
 
 			source := Source{sourceType: SOURCE_FILE, fileName: args[0]}
 
@@ -56,10 +147,85 @@ func main() {
 		},
 	}
 
+	var cmdRender = &cobra.Command{
+		Use:   "render [pgex file]",
+		Short: "Print the plan tree to stdout",
+		Long:  "Print the plan tree read from stdin, a pgex file or the last pgex file to stdout",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			statView, err := ParseStatView(renderOptions.stat)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			queryRun, source, err := readRenderInput(args)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			rendered, err := RenderPlan(queryRun, source, RenderOptions{
+				Stat:    statView,
+				NoColor: renderOptions.noColor,
+				Width:   renderOptions.width,
+				Bars:    renderOptions.bars,
+			})
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Print(rendered)
+		},
+	}
+
+	cmdRender.Flags().StringVarP(&renderOptions.stat, "stat", "", "rows", "stat to show for each node: rows, time, exclusive, buffers, wal, cost or none")
+	cmdRender.Flags().BoolVarP(&renderOptions.noColor, "no-color", "", false, "print without colors")
+	cmdRender.Flags().IntVarP(&renderOptions.width, "width", "", 120, "width of the output")
+	cmdRender.Flags().BoolVarP(&renderOptions.bars, "bars", "", false, "draw bars relative to the largest value of the stat")
+
+	var cmdExport = &cobra.Command{
+		Use:   "export [pgex file]",
+		Short: "Export a report of the plan",
+		Long:  "Export a self-contained markdown or html report, a dot or mermaid diagram, or an svg icicle chart or folded stacks, of the plan read from stdin, a pgex file or the last pgex file",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			format, err := ParseExportFormat(exportOptions.format)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			queryRun, _, err := readRenderInput(args)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			report, err := Export(queryRun, format)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+
+			if exportOptions.output == "" {
+				fmt.Print(report)
+				return
+			}
+			if err := os.WriteFile(exportOptions.output, []byte(report), 0666); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmdExport.Flags().StringVarP(&exportOptions.format, "format", "f", "markdown", "format of the report: markdown, html, dot, mermaid, svg or folded")
+	cmdExport.Flags().StringVarP(&exportOptions.output, "output", "o", "", "file to write the report to instead of stdout")
+
 	var rootCmd = &cobra.Command{
 		Use:   "pg_explain",
-		Short: "read explain in json format from stdin",
-		Long:  `read explain in json format from stdin or read last pgex file with no inputs`,
+		Short: "read explain in json, text, yaml or xml format from stdin",
+		Long:  `read explain in json, text, yaml or xml format from stdin or read last pgex file with no inputs`,
 		Args:  cobra.MinimumNArgs(0),
 		Run: func(cmd *cobra.Command, args []string) {
 			var source Source
@@ -91,7 +257,19 @@ func main() {
 	rootCmd.PersistentFlags().StringVarP(&cliOptions.password, "password", "", "", "database password")
 	rootCmd.PersistentFlags().StringVarP(&cliOptions.database, "database", "", "", "database name")
 
+	cmdExec.Flags().BoolVarP(&execOptions.wal, "wal", "", false, "include WAL usage in the plan (postgres 13+)")
+	cmdExec.Flags().StringArrayVarP(&execOptions.params, "param", "", nil, "value of a $n parameter of the query as n=value, e.g. --param 1=42")
+	cmdExec.Flags().StringVarP(&execOptions.paramsFile, "params-file", "", "", "file with a n=value parameter on each line, defaults to the .params file next to the sql file")
+	cmdExec.Flags().BoolVarP(&execOptions.genericPlan, "generic-plan", "", false, "explain a query with parameters as a generic plan without values or ANALYZE (postgres 16+)")
+	cmdExec.Flags().DurationVarP(&execOptions.timeout, "timeout", "", 0, "cancel statements running longer than the timeout, e.g. 30s, overrides statement_timeout of pgex.conf")
+	cmdExec.Flags().BoolVarP(&RunCommit, "commit", "", false, "commit the changes of INSERT, UPDATE, DELETE and MERGE statements instead of rolling them back")
+	cmdExec.Flags().IntVarP(&RunStatement, "statement", "", 0, "number of the statement of the file to explain, the statements before it are run first as setup, defaults to the last statement")
+	cmdExec.Flags().BoolVarP(&execOptions.pick, "pick", "", false, "pick the statement of the file to explain from a list")
+	cmdExec.Flags().BoolVarP(&execOptions.verbose, "verbose", "", false, "include output columns and schema qualified names in the plan")
+
 	rootCmd.AddCommand(cmdExec)
+	rootCmd.AddCommand(cmdRender)
+	rootCmd.AddCommand(cmdExport)
 
 	cmdVersion := &cobra.Command{
 		Use:   "version",
@@ -106,6 +284,31 @@ func main() {
 	rootCmd.Execute()
 }
 
+// readRenderInput reads the plan from stdin when it is piped, otherwise from
+// the given pgex file or the last pgex file.
+func readRenderInput(args []string) (QueryRun, Source, error) {
+	stat, _ := os.Stdin.Stat()
+	if len(args) == 0 && (stat.Mode()&os.ModeCharDevice) == 0 {
+		input, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return QueryRun{}, Source{}, err
+		}
+		return QueryRun{result: string(input)}, Source{sourceType: SOURCE_STDIN, input: string(input)}, nil
+	}
+
+	var queryRun QueryRun
+	var err error
+	if len(args) == 1 {
+		queryRun, err = loadQueryRun(args[0])
+	} else {
+		queryRun, err = latestQueryRun()
+	}
+	if err != nil {
+		return QueryRun{}, Source{}, err
+	}
+	return queryRun, Source{sourceType: SOURCE_PGEX, fileName: queryRun.pgexPointer}, nil
+}
+
 func LoadSqlConfig() error {
 	if len(cliOptions.configPaths) == 0 {
 		if _, err := os.Stat("./pgex.conf"); err == nil {
@@ -210,6 +413,18 @@ func appendConfigFromFile(path string) error {
 		PGEnvvars["PGDATABASE"] = database
 	}
 
+	if timeout, ok := file.Get("database", "statement_timeout"); ok {
+		d, err := time.ParseDuration(timeout)
+		if err != nil {
+			return fmt.Errorf("error while parsing statement_timeout property: %w", err)
+		}
+		StatementTimeout = d
+	}
+
+	if err := RunExplainOptions.applyExplainConfig(file.Get); err != nil {
+		return err
+	}
+
 	if user, ok := file.Get("database", "user"); ok {
 		PGEnvvars["PGUSER"] = user
 	}
@@ -219,12 +434,12 @@ func appendConfigFromFile(path string) error {
 	return nil
 }
 
-func ExecuteExplain(query string, settings []Setting) (string, error) {
+func ExecuteExplain(ctx context.Context, setup []string, query string, settings []Setting, rollback bool) (string, error) {
 	pgConn := Connection{
 		connConfig: ConnConfig,
 	}
 
-	err := pgConn.Connect()
+	err := pgConn.Connect(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -232,10 +447,39 @@ func ExecuteExplain(query string, settings []Setting) (string, error) {
 	defer pgConn.Close()
 
 	for _, setting := range settings {
-		err := pgConn.SetSetting(setting)
+		err := pgConn.SetSetting(ctx, setting)
 		if err != nil {
 			return "", err
 		}
 	}
-	return pgConn.ExecuteExplain(query)
+	if StatementTimeout > 0 {
+		timeout := Setting{name: "statement_timeout", setting: fmt.Sprintf("%dms", StatementTimeout.Milliseconds())}
+		if err := pgConn.SetSetting(ctx, timeout); err != nil {
+			return "", err
+		}
+	}
+	if rollback {
+		return pgConn.ExecuteExplainRolledBack(ctx, setup, query)
+	}
+	if err := pgConn.Execute(ctx, setup); err != nil {
+		return "", err
+	}
+	return pgConn.ExecuteExplain(ctx, query)
+}
+
+// cancelReason tells whether the query was cancelled by the user or timed out,
+// it is empty when the error isn't a cancellation.
+func cancelReason(err error) string {
+	if errors.Is(err, context.Canceled) {
+		return "cancelled"
+	}
+	var pgErr *pgconn.PgError
+	// SQLSTATE query_canceled
+	if errors.As(err, &pgErr) && pgErr.Code == "57014" {
+		if strings.Contains(pgErr.Message, "statement timeout") {
+			return "timed out"
+		}
+		return "cancelled"
+	}
+	return ""
 }
@@ -0,0 +1,116 @@
+package main
+
+import "maps"
+
+// Tree navigation over the displayed nodes, using the positions of the nodes
+// in the current view.
+
+// selectDisplayed moves the cursor to the displayed node at the index.
+func (m *Model) selectDisplayed(index int) {
+	if index < 0 || index >= len(m.DisplayNodes) {
+		return
+	}
+	m.ctx.Cursor = index
+	m.ctx.SelectedNode = m.DisplayNodes[index]
+}
+
+func (m *Model) moveToParent() {
+	parent := m.ctx.SelectedNode.ViewPosition(m.ctx).Parent
+	for i, node := range m.DisplayNodes {
+		if node.Position.Id == parent {
+			m.selectDisplayed(i)
+			return
+		}
+	}
+}
+
+func (m *Model) moveToFirstChild() {
+	id := m.ctx.SelectedNode.Position.Id
+	for i := m.ctx.Cursor + 1; i < len(m.DisplayNodes); i++ {
+		if m.DisplayNodes[i].ViewPosition(m.ctx).Parent == id {
+			m.selectDisplayed(i)
+			return
+		}
+	}
+}
+
+// moveToSibling moves to the next sibling when direction is 1 and to the
+// previous one when it is -1.
+func (m *Model) moveToSibling(direction int) {
+	parent := m.ctx.SelectedNode.ViewPosition(m.ctx).Parent
+	if parent == 0 {
+		return
+	}
+	for i := m.ctx.Cursor + direction; i >= 0 && i < len(m.DisplayNodes); i += direction {
+		if m.DisplayNodes[i].ViewPosition(m.ctx).Parent == parent {
+			m.selectDisplayed(i)
+			return
+		}
+	}
+}
+
+// moveToWorst moves to the node with the highest score, unfolding and
+// unfiltering the tree when that node isn't displayed.
+func (m *Model) moveToWorst(score func(node PlanNode) float64) {
+	if len(m.nodes) == 0 {
+		return
+	}
+
+	worst := m.nodes[0]
+	for _, node := range m.nodes {
+		if node.Display(m.ctx) && score(node) > score(worst) {
+			worst = node
+		}
+	}
+
+	m.revealNode(worst)
+	for i, node := range m.DisplayNodes {
+		if node.Position.Id == worst.Position.Id {
+			m.selectDisplayed(i)
+			return
+		}
+	}
+}
+
+// revealNode unfolds the ancestors of the node and drops the search filter if
+// it would hide the node.
+func (m *Model) revealNode(node PlanNode) {
+	if m.ctx.FilterMatches && !matchingWithAncestors(m.nodes, m.ctx)[node.Position.Id] {
+		m.ctx.FilterMatches = false
+	}
+
+	if foldedAncestor(node, m.nodes, m.ctx) != 0 {
+		folded := maps.Clone(m.ctx.Folded)
+		parent := node.ViewPosition(m.ctx).Parent
+		for parent != 0 {
+			delete(folded, parent)
+			parent = m.nodes[parent-1].ViewPosition(m.ctx).Parent
+		}
+		m.ctx.Folded = folded
+	}
+
+	m.SetDisplayNodes(m.nodes)
+}
+
+func exclusiveTimeScore(node PlanNode) float64 {
+	return node.Analyzed.ExclusiveTime
+}
+
+func misestimateScore(node PlanNode) float64 {
+	factor, _ := node.RowEstimateFactor()
+	return factor
+}
+
+// selfBuffersReadScore is the number of buffers the node read itself, the
+// counts of a node include those of its children.
+func selfBuffersReadScore(nodes []PlanNode) func(node PlanNode) float64 {
+	return func(node PlanNode) float64 {
+		read := node.Analyzed.SharedBuffersRead + node.Analyzed.LocalBuffersRead
+		for _, child := range nodes {
+			if child.Position.Parent == node.Position.Id {
+				read -= child.Analyzed.SharedBuffersRead + child.Analyzed.LocalBuffersRead
+			}
+		}
+		return float64(max(0, read))
+	}
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"pg-explain/sqlsplit"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// QueryParam is the value bound to the $n parameter of a query.
+type QueryParam struct {
+	number int
+	value  string
+}
+
+// Name of the statement prepared to explain a query with parameters.
+var preparedStatementName = "pgex_query"
+
+// ParseParam reads a parameter given as 1=42 or $1=42.
+func ParseParam(param string) (QueryParam, error) {
+	number, value, ok := strings.Cut(param, "=")
+	if !ok {
+		return QueryParam{}, fmt.Errorf("param '%s' should be given as number=value, e.g. 1=42", param)
+	}
+	n, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(number), "$"))
+	if err != nil || n < 1 {
+		return QueryParam{}, fmt.Errorf("param '%s' should start with the number of the parameter, e.g. 1=42", param)
+	}
+	return QueryParam{number: n, value: value}, nil
+}
+
+// setParam replaces the value of the parameter or adds it, keeping the params
+// sorted by number.
+func setParam(params []QueryParam, param QueryParam) []QueryParam {
+	for i := range params {
+		if params[i].number == param.number {
+			params[i] = param
+			return params
+		}
+	}
+	params = append(params, param)
+	slices.SortFunc(params, func(a, b QueryParam) int {
+		return a.number - b.number
+	})
+	return params
+}
+
+// FindParam returns the parameter with the number or nil.
+func FindParam(params []QueryParam, number int) *QueryParam {
+	for i := range params {
+		if params[i].number == number {
+			return &params[i]
+		}
+	}
+	return nil
+}
+
+// ReadParamsFile reads a params file with a number=value parameter on each
+// line, blank lines and lines starting with # are skipped.
+func ReadParamsFile(path string) ([]QueryParam, error) {
+	body, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	params := []QueryParam{}
+	for i, line := range strings.Split(string(body), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		param, err := ParseParam(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, i+1, err)
+		}
+		params = setParam(params, param)
+	}
+	return params, nil
+}
+
+// ParamsSidecar is the params file read by default for a sql file, the file
+// with the same name and a .params extension, e.g. my_query.params.
+func ParamsSidecar(sqlFile string) string {
+	return strings.TrimSuffix(sqlFile, filepath.Ext(sqlFile)) + ".params"
+}
+
+// LoadRunParams collects the params of a sql file from the params file, or
+// the sidecar when no file is given, and the params passed as flags, which
+// take precedence.
+func LoadRunParams(sqlFile string, paramsFile string, flagParams []string) ([]QueryParam, error) {
+	params := []QueryParam{}
+
+	if paramsFile == "" {
+		if _, err := os.Stat(ParamsSidecar(sqlFile)); err == nil {
+			paramsFile = ParamsSidecar(sqlFile)
+		}
+	}
+	if paramsFile != "" {
+		fileParams, err := ReadParamsFile(paramsFile)
+		if err != nil {
+			return nil, err
+		}
+		params = fileParams
+	}
+
+	for _, flagParam := range flagParams {
+		param, err := ParseParam(flagParam)
+		if err != nil {
+			return nil, err
+		}
+		params = setParam(params, param)
+	}
+	return params, nil
+}
+
+// MissingParams returns the numbers of the parameters of the query without a
+// value.
+func MissingParams(query string, params []QueryParam) []int {
+	missing := []int{}
+	for _, number := range sqlsplit.Placeholders(query) {
+		if FindParam(params, number) == nil {
+			missing = append(missing, number)
+		}
+	}
+	slices.Sort(missing)
+	return missing
+}
+
+// PromptParams asks for the value of each missing parameter of the query.
+func PromptParams(query string, params []QueryParam, in io.Reader, out io.Writer) ([]QueryParam, error) {
+	reader := bufio.NewReader(in)
+	for _, number := range MissingParams(query, params) {
+		fmt.Fprintf(out, "$%d: ", number)
+		value, err := reader.ReadString('\n')
+		if err != nil && (err != io.EOF || value == "") {
+			return nil, fmt.Errorf("no value for $%d: %w", number, err)
+		}
+		params = setParam(params, QueryParam{number: number, value: strings.TrimRight(value, "\r\n")})
+	}
+	return params, nil
+}
+
+// literal quotes the value as a string constant, its type is resolved from the
+// prepared statement like that of any untyped constant.
+func (param QueryParam) literal() string {
+	return "'" + strings.ReplaceAll(param.value, "'", "''") + "'"
+}
+
+// PrepareStatement prepares the query so that it can be explained with the
+// params bound by executing it.
+func (q QueryRun) PrepareStatement() string {
+	return fmt.Sprintf("prepare %s as %s", preparedStatementName, q.query)
+}
+
+// QueryParams keeps the params of the placeholders of the query.
+func QueryParams(query string, params []QueryParam) []QueryParam {
+	placeholders := sqlsplit.Placeholders(query)
+	used := []QueryParam{}
+	for _, param := range params {
+		if slices.Contains(placeholders, param.number) {
+			used = append(used, param)
+		}
+	}
+	return used
+}
+
+// arguments are the values of $1 to the last placeholder of the query in
+// order, as the prepared statement takes them by position.
+func (q QueryRun) arguments() ([]string, error) {
+	placeholders := sqlsplit.Placeholders(q.query)
+	last := slices.Max(placeholders)
+	arguments := make([]string, 0, last)
+	for number := 1; number <= last; number++ {
+		if !slices.Contains(placeholders, number) {
+			return nil, fmt.Errorf("the query uses $%d but not $%d, parameters must be numbered from $1 without gaps", last, number)
+		}
+		param := FindParam(q.params, number)
+		if param == nil {
+			return nil, fmt.Errorf("no value for $%d", number)
+		}
+		arguments = append(arguments, param.literal())
+	}
+	return arguments, nil
+}
+
+func (q QueryRun) executeStatement() (string, error) {
+	arguments, err := q.arguments()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("execute %s(%s)", preparedStatementName, strings.Join(arguments, ", ")), nil
+}
+
+// usesPreparedStatement is true when the query has placeholders bound to the
+// params rather than being explained as a generic plan.
+func (q QueryRun) usesPreparedStatement() bool {
+	return len(sqlsplit.Placeholders(q.query)) > 0 && !q.options.GenericPlan
+}
+
+// SetupStatements are run on the connection before the explain, the setup
+// statements of the sql file followed by the prepared query.
+func (q QueryRun) SetupStatements() []string {
+	statements := slices.Clone(q.setup)
+	if q.usesPreparedStatement() {
+		statements = append(statements, q.PrepareStatement())
+	}
+	return statements
+}
+
+// explainTarget is the statement following the EXPLAIN options.
+func (q QueryRun) explainTarget() (string, error) {
+	if q.usesPreparedStatement() {
+		return q.executeStatement()
+	}
+	return q.query, nil
+}
+
+// paramsComment lists the bound params as sql comments shown below the query.
+func (q QueryRun) paramsComment() string {
+	var buf strings.Builder
+	if q.options.GenericPlan {
+		buf.WriteString("-- generic plan\n")
+		return buf.String()
+	}
+	for _, param := range q.params {
+		buf.WriteString(fmt.Sprintf("-- $%d = %s\n", param.number, param.literal()))
+	}
+	return buf.String()
+}
+
+// DisplaySql is the query preceded by its setup statements and followed by
+// the params it was explained with.
+func (q QueryRun) DisplaySql() string {
+	sql := q.query
+	if len(q.setup) > 0 {
+		sql = strings.Join(q.setup, "\n") + "\n\n" + sql
+	}
+	comment := q.paramsComment()
+	if comment == "" {
+		return sql
+	}
+	return strings.TrimRight(sql, "\n") + "\n\n" + comment
+}
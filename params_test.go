@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseParam(t *testing.T) {
+	param, err := ParseParam("1=42")
+	assert.NoError(t, err)
+	assert.Equal(t, QueryParam{number: 1, value: "42"}, param)
+
+	param, err = ParseParam("$2=a=b")
+	assert.NoError(t, err)
+	assert.Equal(t, QueryParam{number: 2, value: "a=b"}, param)
+
+	_, err = ParseParam("42")
+	assert.Error(t, err)
+	_, err = ParseParam("id=42")
+	assert.Error(t, err)
+	_, err = ParseParam("0=42")
+	assert.Error(t, err)
+}
+
+func TestLoadRunParams(t *testing.T) {
+	dir := t.TempDir()
+	sqlFile := filepath.Join(dir, "orders.sql")
+	sidecar := "# customer and date\n1=42\n\n$2=2024-01-01\n"
+	if err := os.WriteFile(filepath.Join(dir, "orders.params"), []byte(sidecar), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	params, err := LoadRunParams(sqlFile, "", []string{"2=2024-06-01", "3=open"})
+	assert.NoError(t, err)
+	assert.Equal(t, []QueryParam{{1, "42"}, {2, "2024-06-01"}, {3, "open"}}, params)
+
+	params, err = LoadRunParams(filepath.Join(dir, "other.sql"), "", nil)
+	assert.NoError(t, err)
+	assert.Empty(t, params)
+
+	badFile := filepath.Join(dir, "bad.params")
+	if err := os.WriteFile(badFile, []byte("1=42\nnope\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	_, err = LoadRunParams(sqlFile, badFile, nil)
+	assert.ErrorContains(t, err, "bad.params:2:")
+}
+
+func TestPromptParams(t *testing.T) {
+	query := "select * from orders where customer_id = $1 and created_at > $2 and status <> '$3' -- $4\n and customer_id <> $1"
+	assert.Equal(t, []int{2}, MissingParams(query, []QueryParam{{1, "42"}}))
+
+	var out bytes.Buffer
+	params, err := PromptParams(query, nil, strings.NewReader("42\n2024-01-01\n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, "$1: $2: ", out.String())
+	assert.Equal(t, []QueryParam{{1, "42"}, {2, "2024-01-01"}}, params)
+
+	_, err = PromptParams(query, nil, strings.NewReader("42\n"), &out)
+	assert.ErrorContains(t, err, "no value for $2")
+}
+
+func TestPreparedStatement(t *testing.T) {
+	queryRun := QueryRun{
+		query:  "select * from orders where customer_id = $1 and note = $2;",
+		params: []QueryParam{{1, "42"}, {2, "it's"}},
+	}
+
+	assert.Equal(t, []string{"prepare pgex_query as select * from orders where customer_id = $1 and note = $2;"}, queryRun.SetupStatements())
+	explainAnalyze, err := queryRun.WithExplainAnalyze()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(explainAnalyze, ") execute pgex_query('42', 'it''s')"))
+	explain, err := queryRun.WithExplain()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(explain, ") execute pgex_query('42', 'it''s')"))
+	assert.Equal(t, "select * from orders where customer_id = $1 and note = $2;\n\n-- $1 = '42'\n-- $2 = 'it''s'\n", queryRun.DisplaySql())
+
+	queryRun.options.GenericPlan = true
+	assert.Empty(t, queryRun.SetupStatements())
+	explainAnalyze, err = queryRun.WithExplainAnalyze()
+	assert.NoError(t, err)
+	assert.Contains(t, explainAnalyze, "generic_plan")
+	assert.NotContains(t, explainAnalyze, "analyze")
+	assert.True(t, strings.HasSuffix(explainAnalyze, ") select * from orders where customer_id = $1 and note = $2;"))
+
+	assert.Equal(t, "select 1", QueryRun{query: "select 1"}.DisplaySql())
+}
+
+func TestPreparedStatementArguments(t *testing.T) {
+	query := "select * from orders where note = $2 and customer_id = $1"
+	params := QueryParams(query, []QueryParam{{1, "42"}, {2, "open"}, {3, "unused"}})
+	assert.Equal(t, []QueryParam{{1, "42"}, {2, "open"}}, params)
+
+	queryRun := QueryRun{query: query, params: []QueryParam{{2, "open"}, {1, "42"}}}
+	explain, err := queryRun.WithExplain()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(explain, ") execute pgex_query('42', 'open')"))
+
+	queryRun.params = []QueryParam{{1, "42"}}
+	_, err = queryRun.WithExplain()
+	assert.EqualError(t, err, "no value for $2")
+
+	queryRun = QueryRun{query: "select $1, $3", params: []QueryParam{{1, "1"}, {3, "3"}}}
+	_, err = queryRun.WithExplain()
+	assert.EqualError(t, err, "the query uses $3 but not $2, parameters must be numbered from $1 without gaps")
+
+	// Params that aren't used don't make the query prepared.
+	queryRun = QueryRun{query: "select 1", params: []QueryParam{{1, "42"}}}
+	assert.Empty(t, queryRun.SetupStatements())
+	explain, err = queryRun.WithExplain()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasSuffix(explain, ") select 1"))
+}
+
+func TestPgexParams(t *testing.T) {
+	queryRun := QueryRun{
+		query:  "select * from orders where customer_id = $1",
+		result: "[]",
+		params: []QueryParam{{1, "say \"hi\""}},
+	}
+	content := queryRun.pgexFileContent()
+	assert.Contains(t, content, "# param $1: \"say \\\"hi\\\"\"\n")
+
+	pgexFile := filepath.Join(t.TempDir(), "20241206112818_orders.pgex")
+	if err := os.WriteFile(pgexFile, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadQueryRun(pgexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, queryRun.params, loaded.params)
+	assert.False(t, loaded.options.GenericPlan)
+
+	queryRun.options = ExplainOptions{GenericPlan: true}
+	queryRun.optionsRecorded = true
+	assert.Contains(t, queryRun.pgexFileContent(), "# explain: generic_plan\n")
+	assert.NotContains(t, queryRun.pgexFileContent(), "# param")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/charmbracelet/lipgloss"
 	"github.com/charmbracelet/x/ansi"
 )
 
@@ -25,6 +26,9 @@ type PlanNode struct {
 	Position           Position
 	JoinViewPosition   Position
 	RelationName       string
+	Schema             string
+	Alias              string
+	Output             []string
 	IsGather           bool
 	PlannedWorkers     int
 	StartupCost        float64
@@ -33,6 +37,9 @@ type PlanNode struct {
 	IndexCond          string
 	Filter             string
 	HashCond           string
+	MergeCond          string
+	JoinFilter         string
+	RecheckCond        string
 	GroupKey           []string
 	SortKeys           []string
 	PresortKeys        []string
@@ -50,6 +57,9 @@ type PlanNode struct {
 	Operation          string
 	JoinType           string
 	SubPlanName        string
+
+	// Number of nodes hidden below this node when it is folded.
+	foldedCount int
 }
 
 type Analyzed struct {
@@ -62,16 +72,69 @@ type Analyzed struct {
 	ActualLoops       int
 	TempReadBlocks    int
 	TempWriteBlocks   int
+
+	SharedBuffersDirtied int
+	SharedBuffersWritten int
+	LocalBuffersHit      int
+	LocalBuffersRead     int
+	LocalBuffersDirtied  int
+	LocalBuffersWritten  int
+	// Totals of the I/O timings below, before PG16 shared and local reads and
+	// writes were only reported together.
+	IOReadTime        float64
+	IOWriteTime       float64
+	SharedIOReadTime  float64
+	SharedIOWriteTime float64
+	LocalIOReadTime   float64
+	LocalIOWriteTime  float64
+	TempIOReadTime    float64
+	TempIOWriteTime   float64
+
+	WALRecords int
+	// Full page images, written on the first change of a page after a
+	// checkpoint.
+	WALFPI   int
+	WALBytes int
+
+	// Time spent in this node alone across all loops, excluding children.
+	ExclusiveTime    float64
+	ExclusivePercent float64
+
+	RowsRemovedByFilter       int
+	RowsRemovedByIndexRecheck int
+	RowsRemovedByJoinFilter   int
+	HeapFetches               int
+	ExactHeapBlocks           int
+	LossyHeapBlocks           int
+	SortMethod                string
+	SortSpaceUsed             int
+	SortSpaceType             string
+	HashBuckets               int
+	OriginalHashBuckets       int
+	HashBatches               int
+	OriginalHashBatches       int
+	PeakMemoryUsage           int
+
+	Workers []Worker
+}
+
+// Worker holds the statistics of a single parallel worker, the leader's share
+// is the remainder of the node's totals.
+type Worker struct {
+	Number            int
+	StartupTime       float64
+	TotalTime         float64
+	ActualRows        int
+	ActualLoops       int
+	SharedBuffersHit  int
+	SharedBuffersRead int
+	TempReadBlocks    int
+	TempWriteBlocks   int
 }
 
 func (node PlanNode) View(i int, ctx ProgramContext) string {
 
-	var viewPosition Position
-	if ctx.JoinView {
-		viewPosition = node.JoinViewPosition
-	} else {
-		viewPosition = node.Position
-	}
+	viewPosition := node.ViewPosition(ctx)
 
 	var styles Styles
 	if ctx.Cursor == i {
@@ -110,11 +173,15 @@ func (node PlanNode) View(i int, ctx ProgramContext) string {
 	if ctx.JoinView && node.RelationName != "" {
 		buf.WriteString(styles.NodeName.Render(node.Name()))
 		if ctx.DisplayRelations {
-			buf.WriteString(styles.Relation.Render(" " + node.RelationName))
+			buf.WriteString(styles.Relation.Render(" " + node.RelationLabel()))
 		}
 	} else {
 		buf.WriteString(styles.Workers.Render(node.label()))
-		buf.WriteString(styles.NodeName.Render(node.Name()))
+		if node.Matches(ctx.SearchPattern) {
+			buf.WriteString(styles.NodeName.Underline(true).Render(node.Name()))
+		} else {
+			buf.WriteString(styles.NodeName.Render(node.Name()))
+		}
 		if node.CteName != "" {
 			buf.WriteString(styles.Relation.Render(" " + node.CteName))
 		}
@@ -122,31 +189,69 @@ func (node PlanNode) View(i int, ctx ProgramContext) string {
 			buf.WriteString(styles.Relation.Render(" " + node.FunctionName))
 		}
 		if node.RelationName != "" && ctx.DisplayRelations {
-			buf.WriteString(styles.Relation.Render(" " + node.RelationName))
+			buf.WriteString(styles.Relation.Render(" " + node.RelationLabel()))
 		}
 	}
 
+	if node.foldedCount > 0 {
+		buf.WriteString(styles.Gutter.Render(fmt.Sprintf(" ▸ %d hidden", node.foldedCount)))
+	}
+
 	result := buf.String()
 
-	needed := ctx.Width - ansi.StringWidth(result) - 2
+	needed := ctx.Width - ansi.StringWidth(result) - 2 - barSpace(ctx)
 
 	if ctx.StatDisplay == DisplayRows {
 		buf.WriteString(node.rows(styles, needed, ctx))
 	} else if ctx.StatDisplay == DisplayBuffers {
-		buf.WriteString(node.buffers(styles, needed))
+		buf.WriteString(node.buffers(styles, needed, ctx))
+	} else if ctx.StatDisplay == DisplayWAL {
+		buf.WriteString(node.wal(styles, needed))
 	} else if ctx.StatDisplay == DisplayCost {
 		buf.WriteString(node.costs(styles, needed))
 	} else if ctx.StatDisplay == DisplayTime {
 		buf.WriteString(node.times(styles, needed))
+	} else if ctx.StatDisplay == DisplayExclusive {
+		buf.WriteString(node.exclusive(styles, needed))
 	} else if ctx.StatDisplay == DisplayNothing {
 		buf.WriteString(styles.Everything.Render(fmt.Sprintf("%*s", needed, "")))
 	}
+	buf.WriteString(node.barView(styles, ctx))
 
 	buf.WriteString("\n")
 
 	return buf.String()
 }
 
+// Matches reports whether the search pattern is found, ignoring case, in the
+// name of the node, the objects it works on or its conditions.
+func (node PlanNode) Matches(pattern string) bool {
+	if pattern == "" {
+		return false
+	}
+
+	pattern = strings.ToLower(pattern)
+	fields := []string{
+		node.Name(), node.RelationName, node.Alias, node.IndexName, node.CteName,
+		node.SubPlanName, node.FunctionName, node.Filter, node.IndexCond,
+		node.HashCond, node.MergeCond, node.JoinFilter, node.RecheckCond, node.TidCond,
+	}
+	for _, field := range fields {
+		if strings.Contains(strings.ToLower(field), pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ViewPosition is the position of the node in the current view.
+func (node PlanNode) ViewPosition(ctx ProgramContext) Position {
+	if ctx.JoinView {
+		return node.JoinViewPosition
+	}
+	return node.Position
+}
+
 func (node PlanNode) Display(ctx ProgramContext) bool {
 	if ctx.JoinView {
 		return node.JoinViewPosition.Display
@@ -186,12 +291,19 @@ func (node PlanNode) Name() string {
 	}
 	if node.NodeType == "ModifyTable" {
 		nodeName = node.Operation
-	} else {
-		nodeName = nodeName
 	}
 	return strings.ReplaceAll(strings.Trim(fmt.Sprintf("%s %s %s", node.PartialMode, nodeName, joinType), " "), "  ", " ")
 }
 
+// RelationLabel names the relation along with its alias when that differs, so
+// that "orders o1" and "orders o2" of a self join can be told apart.
+func (node PlanNode) RelationLabel() string {
+	if node.Alias != "" && node.Alias != node.RelationName {
+		return node.RelationName + " " + node.Alias
+	}
+	return node.RelationName
+}
+
 func (node PlanNode) label() string {
 	if node.SubPlanName != "" {
 		return fmt.Sprintf("%s: ", node.SubPlanName)
@@ -199,23 +311,62 @@ func (node PlanNode) label() string {
 	return ""
 }
 
-func (node PlanNode) buffers(styles Styles, space int) string {
+func (node PlanNode) buffers(styles Styles, space int, ctx ProgramContext) string {
 	var buf strings.Builder
-	totalBuffers := formatUnderscores(node.Analyzed.SharedBuffersRead + node.Analyzed.SharedBuffersHit)
-	readBuffers := formatUnderscores(node.Analyzed.SharedBuffersRead)
+	var first, second string
 
-	if false {
-		buf.WriteString(styles.Bracket.Render(" ["))
-		buf.WriteString(styles.Everything.Render("total="))
-		buf.WriteString(totalBuffers)
-		buf.WriteString(styles.Everything.Render(" read="))
-		buf.WriteString(readBuffers)
-		buf.WriteString(styles.Bracket.Render("]"))
+	firstValue, secondValue := node.buffersColumns(ctx.BuffersDisplay)
+	if ctx.BuffersDisplay == BuffersIOTime {
+		first = formatUnderscoresFloat(firstValue)
+		second = formatUnderscoresFloat(secondValue)
 	} else {
-		columns := fmt.Sprintf("%5s%15s", totalBuffers, readBuffers)
-		buf.WriteString(styles.Value.Render(fmt.Sprintf("%*s", space, columns)))
+		first = formatUnderscores(int(firstValue))
+		second = formatUnderscores(int(secondValue))
 	}
 
+	columns := fmt.Sprintf("%5s%15s", first, second)
+	buf.WriteString(styles.Value.Render(fmt.Sprintf("%*s", space, columns)))
+
+	return buf.String()
+}
+
+// buffersColumns returns the values of the two columns of the buffers view.
+func (node PlanNode) buffersColumns(buffersDisplay BuffersView) (float64, float64) {
+	analyzed := node.Analyzed
+	switch buffersDisplay {
+	case BuffersShared:
+		return float64(analyzed.SharedBuffersRead + analyzed.SharedBuffersHit), float64(analyzed.SharedBuffersRead)
+	case BuffersDirtied:
+		return float64(analyzed.SharedBuffersDirtied + analyzed.LocalBuffersDirtied), float64(analyzed.SharedBuffersWritten + analyzed.LocalBuffersWritten)
+	case BuffersLocal:
+		return float64(analyzed.LocalBuffersRead + analyzed.LocalBuffersHit), float64(analyzed.LocalBuffersRead)
+	case BuffersTemp:
+		return float64(analyzed.TempReadBlocks), float64(analyzed.TempWriteBlocks)
+	case BuffersIOTime:
+		return analyzed.IOReadTime, analyzed.IOWriteTime
+	}
+	return 0, 0
+}
+
+func (node PlanNode) wal(styles Styles, space int) string {
+	records := formatUnderscores(node.Analyzed.WALRecords)
+	fpi := formatUnderscores(node.Analyzed.WALFPI)
+	bytes := formatUnderscores(node.Analyzed.WALBytes)
+
+	columns := fmt.Sprintf("%5s%15s%13s", records, fpi, bytes)
+	padded := fmt.Sprintf("%*s", space, columns)
+	split := len(padded) - 13 - len(fpi)
+
+	fpiStyle := styles.Value
+	if node.Analyzed.WALFPI > 0 {
+		fpiStyle = styles.Caution
+	}
+
+	var buf strings.Builder
+	buf.WriteString(styles.Value.Render(padded[:split]))
+	buf.WriteString(fpiStyle.Render(fpi))
+	buf.WriteString(styles.Value.Render(padded[split+len(fpi):]))
+
 	return buf.String()
 }
 
@@ -262,36 +413,109 @@ func (node PlanNode) times(styles Styles, space int) string {
 	return buf.String()
 }
 
+func (node PlanNode) exclusive(styles Styles, space int) string {
+	exclusiveTime := formatUnderscoresFloat(node.Analyzed.ExclusiveTime)
+	percent := fmt.Sprintf("%.1f%%", node.Analyzed.ExclusivePercent)
+
+	columns := fmt.Sprintf("%5s%15s", exclusiveTime, percent)
+	padded := fmt.Sprintf("%*s", space, columns)
+	split := len(padded) - len(percent)
+
+	var buf strings.Builder
+	buf.WriteString(styles.Value.Render(padded[:split]))
+	buf.WriteString(exclusiveStyle(node.Analyzed.ExclusivePercent, styles).Render(percent))
+
+	return buf.String()
+}
+
+func exclusiveStyle(percent float64, styles Styles) lipgloss.Style {
+	if percent >= 50 {
+		return styles.Warning
+	} else if percent >= 10 {
+		return styles.Caution
+	}
+	return styles.Value
+}
+
 func (node PlanNode) rows(styles Styles, space int, ctx ProgramContext) string {
 
 	separatedPlanRows := formatUnderscores(node.PlanRows)
 	separatedActualRows := formatUnderscores(node.Analyzed.ActualRows)
+	// Without costs there are no estimated rows to compare with.
+	if !ctx.Costs {
+		separatedPlanRows = "-"
+	}
 
 	var buf strings.Builder
 
-	if ctx.Analyzed {
-		if node.ParentIsNestedLoop && node.ParentRelationship == "Inner" {
-			separatedActualRows = fmt.Sprintf("(%s) → %s", formatUnderscores(node.Analyzed.ActualLoops), separatedActualRows)
-		} else if node.Analyzed.ActualLoops > 1 {
-			separatedActualRows = fmt.Sprintf("%s(%s)", separatedActualRows, formatUnderscores(node.Analyzed.ActualLoops))
-		}
-	} else {
-		separatedActualRows = "- "
+	if !ctx.Analyzed {
+		columns := fmt.Sprintf("%5s%15s", separatedPlanRows, "- ")
+		buf.WriteString(styles.Value.Render(fmt.Sprintf("%*s", space, columns)))
+		return buf.String()
 	}
 
-	columns := fmt.Sprintf("%5s%15s", separatedPlanRows, separatedActualRows)
-	buf.WriteString(styles.Value.Render(fmt.Sprintf("%*s", space, columns)))
+	if node.ParentIsNestedLoop && node.ParentRelationship == "Inner" {
+		separatedActualRows = fmt.Sprintf("(%s) → %s", formatUnderscores(node.Analyzed.ActualLoops), separatedActualRows)
+	} else if node.Analyzed.ActualLoops > 1 {
+		separatedActualRows = fmt.Sprintf("%s(%s)", separatedActualRows, formatUnderscores(node.Analyzed.ActualLoops))
+	}
+
+	factor, under := node.RowEstimateFactor()
+	statusStyle := getRowStatus(factor, styles)
+	estimate := formatEstimateFactor(factor, under)
+	if !ctx.Costs {
+		statusStyle = styles.Value
+		estimate = "- "
+	}
+
+	buf.WriteString(styles.Value.Render(fmt.Sprintf("%*s", max(5, space-28), separatedPlanRows)))
+	buf.WriteString(statusStyle.Render(fmt.Sprintf("%15s", separatedActualRows)))
+	buf.WriteString(statusStyle.Render(fmt.Sprintf("%13s", estimate)))
 
 	return buf.String()
 }
 
-func getRowStatus(percentOfActual float32, styles Styles) string {
-	if percentOfActual < 10 {
-		return styles.Warning.Render(fmt.Sprintf(" %.1f%%", percentOfActual))
-	} else if percentOfActual < 50 {
-		return styles.Caution.Render(fmt.Sprintf(" %.1f%%", percentOfActual))
+// RowEstimateFactor returns how many times the planner misestimated the rows
+// returned by the node and whether the estimate was too low. Plan rows are
+// estimated per loop so they are compared with the actual rows per loop, which
+// is the same as comparing actual×loops with plan rows×loops.
+func (node PlanNode) RowEstimateFactor() (float64, bool) {
+	if node.Analyzed.ActualLoops == 0 {
+		return 1, false
+	}
+
+	planned := float64(max(1, node.PlanRows))
+	actual := float64(max(1, node.Analyzed.ActualRows))
+
+	if actual > planned {
+		return actual / planned, true
+	}
+	return planned / actual, false
+}
+
+func formatEstimateFactor(factor float64, under bool) string {
+	if factor < 1.05 {
+		return "×1"
+	}
+
+	direction := "over"
+	if under {
+		direction = "under"
+	}
+
+	if factor < 10 {
+		return fmt.Sprintf("×%.1f %s", factor, direction)
+	}
+	return fmt.Sprintf("×%s %s", formatUnderscores(int(factor)), direction)
+}
+
+func getRowStatus(factor float64, styles Styles) lipgloss.Style {
+	if factor >= 100 {
+		return styles.Warning
+	} else if factor >= 10 {
+		return styles.Caution
 	} else {
-		return styles.Everything.Render(fmt.Sprintf(" %.1f%%", percentOfActual))
+		return styles.Value
 	}
 }
 
@@ -302,6 +526,28 @@ func (node PlanNode) Content(ctx ProgramContext) string {
 
 	var buf strings.Builder
 
+	if buffers := node.buffersDetail(); buffers != "" {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Buffers: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(buffers))
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.IOReadTime > 0 || node.Analyzed.IOWriteTime > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("I/O Timings: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(node.ioTimingsDetail()))
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.WALRecords > 0 || node.Analyzed.WALBytes > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("WAL: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("records=%s ", formatUnderscores(node.Analyzed.WALRecords))))
+		fpi := fmt.Sprintf("fpi=%s", formatUnderscores(node.Analyzed.WALFPI))
+		if node.Analyzed.WALFPI > 0 {
+			buf.WriteString(ctx.NormalStyle.Caution.Render(fpi))
+		} else {
+			buf.WriteString(ctx.NormalStyle.Everything.Render(fpi))
+		}
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf(" bytes=%s", formatUnderscores(node.Analyzed.WALBytes))))
+		buf.WriteString("\n")
+	}
 	if node.Analyzed.TempReadBlocks > 0 {
 		buf.WriteString(ctx.DetailStyles.Label.Render("Temp Read Blocks: "))
 		buf.WriteString(ctx.DetailStyles.Warning.Render(formatUnderscores(node.Analyzed.TempReadBlocks)))
@@ -318,6 +564,11 @@ func (node PlanNode) Content(ctx ProgramContext) string {
 		buf.WriteString("\n")
 	}
 	if ctx.Analyzed {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Exclusive Time: "))
+		exclusiveTime := fmt.Sprintf("%sms", formatUnderscoresFloat(node.Analyzed.ExclusiveTime))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(exclusiveTime))
+		buf.WriteString(fmt.Sprintf(" %.1f%% of query", node.Analyzed.ExclusivePercent))
+		buf.WriteString("\n")
 		buf.WriteString(ctx.DetailStyles.Label.Render("Actual Loops: "))
 		loops := formatUnderscores(node.Analyzed.ActualLoops)
 		buf.WriteString(ctx.NormalStyle.Everything.Render(loops))
@@ -330,10 +581,30 @@ func (node PlanNode) Content(ctx ProgramContext) string {
 		}
 		buf.WriteString("\n")
 	}
+	// Without costs there are no estimated rows to compare with.
+	if ctx.Analyzed && ctx.Costs && node.Analyzed.ActualLoops > 0 {
+		factor, under := node.RowEstimateFactor()
+		buf.WriteString(ctx.DetailStyles.Label.Render("Row Estimate: "))
+		buf.WriteString(getRowStatus(factor, ctx.NormalStyle).Render(formatEstimateFactor(factor, under)))
+		buf.WriteString(fmt.Sprintf(" planned %s, actual %s per loop", formatUnderscores(node.PlanRows), formatUnderscores(node.Analyzed.ActualRows)))
+		buf.WriteString("\n")
+	}
+	if len(node.Analyzed.Workers) > 0 {
+		buf.WriteString(node.workersContent(ctx))
+	}
 	if node.RelationName != "" {
 		buf.WriteString(ctx.DetailStyles.Label.Render("Relation Name: "))
-		buf.WriteString(ctx.NormalStyle.Relation.Render(node.RelationName))
+		if node.Schema != "" {
+			buf.WriteString(ctx.NormalStyle.Relation.Render(node.Schema + "." + node.RelationName))
+		} else {
+			buf.WriteString(ctx.NormalStyle.Relation.Render(node.RelationName))
+		}
 		buf.WriteString("\n")
+		if node.Alias != "" && node.Alias != node.RelationName {
+			buf.WriteString(ctx.DetailStyles.Label.Render("Alias: "))
+			buf.WriteString(ctx.NormalStyle.Relation.Render(node.Alias))
+			buf.WriteString("\n")
+		}
 	}
 	if node.CteName != "" {
 		buf.WriteString(ctx.DetailStyles.Label.Render("CTE Name: "))
@@ -355,11 +626,79 @@ func (node PlanNode) Content(ctx ProgramContext) string {
 		buf.WriteString(ctx.NormalStyle.Everything.Render(node.IndexCond))
 		buf.WriteString("\n")
 	}
+	if node.RecheckCond != "" {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Recheck Cond: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(node.RecheckCond))
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.RowsRemovedByIndexRecheck > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Rows Removed by Index Recheck: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(formatUnderscores(node.Analyzed.RowsRemovedByIndexRecheck)))
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.ExactHeapBlocks > 0 || node.Analyzed.LossyHeapBlocks > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Heap Blocks: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("exact=%s ", formatUnderscores(node.Analyzed.ExactHeapBlocks))))
+		lossy := fmt.Sprintf("lossy=%s", formatUnderscores(node.Analyzed.LossyHeapBlocks))
+		if node.Analyzed.LossyHeapBlocks > 0 {
+			buf.WriteString(ctx.DetailStyles.Warning.Render(lossy))
+		} else {
+			buf.WriteString(ctx.NormalStyle.Everything.Render(lossy))
+		}
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.HeapFetches > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Heap Fetches: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(formatUnderscores(node.Analyzed.HeapFetches)))
+		buf.WriteString("\n")
+	}
 	if node.HashCond != "" {
 		buf.WriteString(ctx.DetailStyles.Label.Render("Hash Cond: "))
 		buf.WriteString(ctx.NormalStyle.Everything.Render(node.HashCond))
 		buf.WriteString("\n")
 	}
+	if node.MergeCond != "" {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Merge Cond: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(node.MergeCond))
+		buf.WriteString("\n")
+	}
+	if node.JoinFilter != "" {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Join Filter: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(node.JoinFilter))
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.RowsRemovedByJoinFilter > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Rows Removed by Join Filter: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(formatUnderscores(node.Analyzed.RowsRemovedByJoinFilter)))
+		buf.WriteString("\n")
+	}
+	if node.Analyzed.HashBatches > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Hash: "))
+		buckets := fmt.Sprintf("buckets=%s", formatUnderscores(node.Analyzed.HashBuckets))
+		if node.Analyzed.OriginalHashBuckets != node.Analyzed.HashBuckets {
+			buckets += fmt.Sprintf(" (originally %s)", formatUnderscores(node.Analyzed.OriginalHashBuckets))
+		}
+		buf.WriteString(ctx.NormalStyle.Everything.Render(buckets + " "))
+		batches := fmt.Sprintf("batches=%s", formatUnderscores(node.Analyzed.HashBatches))
+		if node.Analyzed.OriginalHashBatches != node.Analyzed.HashBatches {
+			batches += fmt.Sprintf(" (originally %s)", formatUnderscores(node.Analyzed.OriginalHashBatches))
+		}
+		if node.Analyzed.HashBatches > 1 {
+			buf.WriteString(ctx.DetailStyles.Warning.Render(batches))
+		} else {
+			buf.WriteString(ctx.NormalStyle.Everything.Render(batches))
+		}
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf(" memory=%skB", formatUnderscores(node.Analyzed.PeakMemoryUsage))))
+		buf.WriteString("\n")
+	}
+	if node.Output != nil {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Output: "))
+		buf.WriteString("\n")
+		for _, column := range node.Output {
+			buf.WriteString(ctx.NormalStyle.Everything.Render("  " + column))
+			buf.WriteString("\n")
+		}
+	}
 	if node.GroupKey != nil {
 		buf.WriteString(ctx.DetailStyles.Label.Render("Group Keys: "))
 		buf.WriteString(ctx.NormalStyle.Everything.Render(strings.Join(node.GroupKey, ", ")))
@@ -375,11 +714,30 @@ func (node PlanNode) Content(ctx ProgramContext) string {
 		buf.WriteString(ctx.NormalStyle.Everything.Render(strings.Join(node.SortKeys, ", ")))
 		buf.WriteString("\n")
 	}
+	if node.Analyzed.SortMethod != "" {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Sort Method: "))
+		sortMethod := node.Analyzed.SortMethod
+		// The space is left out when the plan doesn't report it.
+		if node.Analyzed.SortSpaceType != "" && node.Analyzed.SortSpaceUsed > 0 {
+			sortMethod = fmt.Sprintf("%s %s: %skB", sortMethod, node.Analyzed.SortSpaceType, formatUnderscores(node.Analyzed.SortSpaceUsed))
+		}
+		if node.Analyzed.SortSpaceType == "Disk" {
+			buf.WriteString(ctx.DetailStyles.Warning.Render(sortMethod))
+		} else {
+			buf.WriteString(ctx.NormalStyle.Everything.Render(sortMethod))
+		}
+		buf.WriteString("\n")
+	}
 	if node.Filter != "" {
 		buf.WriteString(ctx.DetailStyles.Label.Render("Filter: "))
 		buf.WriteString(ctx.NormalStyle.Everything.Render(node.Filter))
 		buf.WriteString("\n")
 	}
+	if node.Analyzed.RowsRemovedByFilter > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Rows Removed by Filter: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(formatUnderscores(node.Analyzed.RowsRemovedByFilter)))
+		buf.WriteString("\n")
+	}
 	if node.PlanWidth > 0 {
 		buf.WriteString(ctx.DetailStyles.Label.Render("Plan Width: "))
 		buf.WriteString(ctx.NormalStyle.Everything.Render(formatUnderscores(node.PlanWidth)))
@@ -388,3 +746,148 @@ func (node PlanNode) Content(ctx ProgramContext) string {
 
 	return buf.String()
 }
+
+// TotalBuffers is the number of shared and local buffers the node accessed,
+// either found in the cache or read.
+func (analyzed Analyzed) TotalBuffers() int {
+	return analyzed.SharedBuffersHit + analyzed.SharedBuffersRead + analyzed.LocalBuffersHit + analyzed.LocalBuffersRead
+}
+
+// WorkerSkew returns the rows processed by the busiest participant divided by
+// the average across all participants, including the leader.
+func (node PlanNode) WorkerSkew() float64 {
+	rows := node.participantRows()
+	if len(rows) == 0 {
+		return 1
+	}
+
+	var total, busiest int
+	for _, r := range rows {
+		total += r
+		busiest = max(busiest, r)
+	}
+	if total == 0 {
+		return 1
+	}
+
+	average := float64(total) / float64(len(rows))
+	return float64(busiest) / average
+}
+
+// participantRows returns the rows of each worker followed by the rows of the
+// leader, which is only present when it did part of the work.
+func (node PlanNode) participantRows() []int {
+	if len(node.Analyzed.Workers) == 0 {
+		return nil
+	}
+
+	rows := make([]int, 0, len(node.Analyzed.Workers)+1)
+	workerRows := 0
+	for _, worker := range node.Analyzed.Workers {
+		rows = append(rows, worker.ActualRows*worker.ActualLoops)
+		workerRows += worker.ActualRows * worker.ActualLoops
+	}
+
+	leaderRows := node.Analyzed.ActualRows*node.Analyzed.ActualLoops - workerRows
+	if node.Analyzed.ActualLoops > len(node.Analyzed.Workers) {
+		rows = append(rows, max(0, leaderRows))
+	}
+	return rows
+}
+
+func (node PlanNode) workersContent(ctx ProgramContext) string {
+	var buf strings.Builder
+
+	skew := node.WorkerSkew()
+	buf.WriteString(ctx.DetailStyles.Label.Render("Workers: "))
+	buf.WriteString(ctx.NormalStyle.Workers.Render(formatUnderscores(len(node.Analyzed.Workers))))
+	skewText := fmt.Sprintf(" skew ×%.2f", skew)
+	if skew >= 3 {
+		buf.WriteString(ctx.DetailStyles.Warning.Render(skewText))
+	} else if skew >= 1.5 {
+		buf.WriteString(ctx.NormalStyle.Caution.Render(skewText))
+	} else {
+		buf.WriteString(ctx.NormalStyle.Everything.Render(skewText))
+	}
+	buf.WriteString("\n")
+
+	buf.WriteString(ctx.NormalStyle.Gutter.Render(fmt.Sprintf("  %-8s%12s%12s%8s%12s%12s", "", "Rows", "Time", "Loops", "Hit", "Read")))
+	buf.WriteString("\n")
+
+	participants := node.participantRows()
+	for i, worker := range node.Analyzed.Workers {
+		row := fmt.Sprintf("  %-8s%12s%12s%8s%12s%12s",
+			fmt.Sprintf("Worker %d", worker.Number),
+			formatUnderscores(participants[i]),
+			formatUnderscoresFloat(worker.TotalTime),
+			formatUnderscores(worker.ActualLoops),
+			formatUnderscores(worker.SharedBuffersHit),
+			formatUnderscores(worker.SharedBuffersRead),
+		)
+		buf.WriteString(ctx.NormalStyle.Everything.Render(row))
+		buf.WriteString("\n")
+	}
+
+	if len(participants) > len(node.Analyzed.Workers) {
+		row := fmt.Sprintf("  %-8s%12s", "Leader", formatUnderscores(participants[len(participants)-1]))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(row))
+		buf.WriteString("\n")
+	}
+
+	return buf.String()
+}
+
+// buffersDetail lists the non-zero buffer counts the way the text format of
+// EXPLAIN does, e.g. "shared hit=10 read=2, local hit=1".
+func (node PlanNode) buffersDetail() string {
+	a := node.Analyzed
+	groups := []struct {
+		name   string
+		labels []string
+		counts []int
+	}{
+		{"shared", []string{"hit", "read", "dirtied", "written"}, []int{a.SharedBuffersHit, a.SharedBuffersRead, a.SharedBuffersDirtied, a.SharedBuffersWritten}},
+		{"local", []string{"hit", "read", "dirtied", "written"}, []int{a.LocalBuffersHit, a.LocalBuffersRead, a.LocalBuffersDirtied, a.LocalBuffersWritten}},
+		{"temp", []string{"read", "written"}, []int{a.TempReadBlocks, a.TempWriteBlocks}},
+	}
+
+	segments := []string{}
+	for _, group := range groups {
+		fields := []string{}
+		for i, count := range group.counts {
+			if count > 0 {
+				fields = append(fields, fmt.Sprintf("%s=%s", group.labels[i], formatUnderscores(count)))
+			}
+		}
+		if len(fields) > 0 {
+			segments = append(segments, group.name+" "+strings.Join(fields, " "))
+		}
+	}
+
+	return strings.Join(segments, ", ")
+}
+
+func (node PlanNode) ioTimingsDetail() string {
+	a := node.Analyzed
+	if a.SharedIOReadTime == 0 && a.SharedIOWriteTime == 0 && a.LocalIOReadTime == 0 && a.LocalIOWriteTime == 0 && a.TempIOReadTime == 0 && a.TempIOWriteTime == 0 {
+		return fmt.Sprintf("read=%.3fms write=%.3fms", a.IOReadTime, a.IOWriteTime)
+	}
+
+	groups := []struct {
+		name  string
+		read  float64
+		write float64
+	}{
+		{"shared", a.SharedIOReadTime, a.SharedIOWriteTime},
+		{"local", a.LocalIOReadTime, a.LocalIOWriteTime},
+		{"temp", a.TempIOReadTime, a.TempIOWriteTime},
+	}
+
+	segments := []string{}
+	for _, group := range groups {
+		if group.read > 0 || group.write > 0 {
+			segments = append(segments, fmt.Sprintf("%s read=%.3fms write=%.3fms", group.name, group.read, group.write))
+		}
+	}
+	return strings.Join(segments, ", ")
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"testing"
 
+	"github.com/acarl005/stripansi"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,3 +50,73 @@ func TestName(t *testing.T) {
 		})
 	}
 }
+
+func TestRowEstimateFactor(t *testing.T) {
+	testCases := []struct {
+		desc   string
+		node   PlanNode
+		factor float64
+		under  bool
+		result string
+	}{
+		{
+			desc:   "Underestimate",
+			node:   PlanNode{PlanRows: 10, Analyzed: Analyzed{ActualRows: 3400, ActualLoops: 1}},
+			factor: 340,
+			under:  true,
+			result: "×340 under",
+		},
+		{
+			desc:   "Overestimate",
+			node:   PlanNode{PlanRows: 250, Analyzed: Analyzed{ActualRows: 100, ActualLoops: 1}},
+			factor: 2.5,
+			under:  false,
+			result: "×2.5 over",
+		},
+		{
+			desc:   "Zero actual rows are treated as one",
+			node:   PlanNode{PlanRows: 1, Analyzed: Analyzed{ActualRows: 0, ActualLoops: 4}},
+			factor: 1,
+			under:  false,
+			result: "×1",
+		},
+		{
+			desc:   "Rows per loop are compared",
+			node:   PlanNode{PlanRows: 5, Analyzed: Analyzed{ActualRows: 5, ActualLoops: 1000}},
+			factor: 1,
+			under:  false,
+			result: "×1",
+		},
+		{
+			desc:   "Never executed",
+			node:   PlanNode{PlanRows: 5000},
+			factor: 1,
+			under:  false,
+			result: "×1",
+		},
+	}
+	for _, tC := range testCases {
+		t.Run(tC.desc, func(t *testing.T) {
+			factor, under := tC.node.RowEstimateFactor()
+			assert.InDelta(t, tC.factor, factor, 0.001)
+			assert.Equal(t, tC.under, under)
+			assert.Equal(t, tC.result, formatEstimateFactor(factor, under))
+		})
+	}
+}
+
+func TestRelationLabel(t *testing.T) {
+	assert.Equal(t, "orders", PlanNode{RelationName: "orders", Alias: "orders"}.RelationLabel())
+	assert.Equal(t, "orders", PlanNode{RelationName: "orders"}.RelationLabel())
+	assert.Equal(t, "orders o1", PlanNode{RelationName: "orders", Alias: "o1"}.RelationLabel())
+}
+
+func TestSortMethodDetail(t *testing.T) {
+	ctx := InitProgramContext()
+	node := PlanNode{NodeType: "Sort", Analyzed: Analyzed{SortMethod: "quicksort", SortSpaceType: "Memory", SortSpaceUsed: 25}}
+	assert.Contains(t, stripansi.Strip(node.Content(ctx)), "Sort Method: quicksort Memory: 25kB\n")
+
+	node.Analyzed.SortSpaceType = ""
+	node.Analyzed.SortSpaceUsed = 0
+	assert.Contains(t, stripansi.Strip(node.Content(ctx)), "Sort Method: quicksort\n")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+var jitTimings = []string{"Generation", "Inlining", "Optimization", "Emission", "Total"}
+var jitOptions = []string{"Inlining", "Optimization", "Expressions", "Deforming"}
+
+// Content describes the query as a whole for the Query section.
+func (ep ExplainPlan) Content(ctx ProgramContext) string {
+	if len(ep.nodes) == 0 {
+		return "No Plan Loaded"
+	}
+
+	var buf strings.Builder
+
+	if ep.planningTime > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Planning Time: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("%.3fms", ep.planningTime)))
+		buf.WriteString("\n")
+	}
+	if ep.planningBuffersHit > 0 || ep.planningBuffersRead > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Planning Buffers: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("hit=%s read=%s", formatUnderscores(ep.planningBuffersHit), formatUnderscores(ep.planningBuffersRead))))
+		buf.WriteString("\n")
+	}
+	if ep.analyzed {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Execution Time: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("%.3fms", ep.executionTime)))
+		buf.WriteString("\n")
+	}
+
+	if len(ep.triggers) > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("Triggers: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("%.3fms", ep.TotalTriggerTime())))
+		buf.WriteString(percentOfExecution(ep.TotalTriggerTime(), ep.executionTime))
+		buf.WriteString("\n")
+		nameWidth := 0
+		for _, trigger := range ep.triggers {
+			nameWidth = max(nameWidth, len(trigger.DisplayName()))
+		}
+		for _, trigger := range ep.triggers {
+			buf.WriteString(ctx.NormalStyle.Relation.Render(fmt.Sprintf("  %-*s", nameWidth, trigger.DisplayName())))
+			buf.WriteString(ctx.NormalStyle.Value.Render(fmt.Sprintf("%12s", fmt.Sprintf("%.3fms", trigger.Time))))
+			buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("%10s calls", formatUnderscores(trigger.Calls))))
+			buf.WriteString("\n")
+		}
+	}
+
+	if ep.jit.Functions > 0 {
+		buf.WriteString(ctx.DetailStyles.Label.Render("JIT: "))
+		buf.WriteString(ctx.NormalStyle.Everything.Render(fmt.Sprintf("%s functions, %.3fms", formatUnderscores(ep.jit.Functions), ep.jit.Timing["Total"])))
+		buf.WriteString(percentOfExecution(ep.jit.Timing["Total"], ep.executionTime))
+		buf.WriteString("\n")
+
+		options := make([]string, 0, len(jitOptions))
+		for _, option := range jitOptions {
+			if value, ok := ep.jit.Options[option]; ok {
+				options = append(options, fmt.Sprintf("%s %t", option, value))
+			}
+		}
+		buf.WriteString(ctx.NormalStyle.Everything.Render("  Options: " + strings.Join(options, ", ")))
+		buf.WriteString("\n")
+
+		timings := make([]string, 0, len(jitTimings))
+		for _, timing := range jitTimings {
+			if value, ok := ep.jit.Timing[timing]; ok && timing != "Total" {
+				timings = append(timings, fmt.Sprintf("%s %.3fms", timing, value))
+			}
+		}
+		if len(timings) > 0 {
+			buf.WriteString(ctx.NormalStyle.Everything.Render("  Timing: " + strings.Join(timings, ", ")))
+			buf.WriteString("\n")
+		}
+	}
+
+	return buf.String()
+}
+
+func (t Trigger) DisplayName() string {
+	name := t.Name
+	if t.ConstraintName != "" {
+		name = "for constraint " + t.ConstraintName
+	}
+	if t.Relation != "" {
+		name = fmt.Sprintf("%s on %s", name, t.Relation)
+	}
+	return name
+}
+
+func percentOfExecution(value float64, executionTime float64) string {
+	if executionTime <= 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %.1f%% of execution", value/executionTime*100)
+}
@@ -3,13 +3,14 @@ package main
 import (
 	"errors"
 	"fmt"
-	"log"
 	"os"
 	"os/user"
 	"path"
 	"path/filepath"
 	"pg-explain/sqlsplit"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,6 +25,21 @@ type QueryRun struct {
 	originalFilename string
 	pgexPointer      string
 	settings         []Setting
+	options          ExplainOptions
+	// The options are known, runs stored before they were recorded only
+	// have the generic plan option.
+	optionsRecorded bool
+	// The query or its setup modifies data and ran in a transaction that was
+	// rolled back.
+	rolledBack bool
+	params     []QueryParam
+	// Statements of the sql file before the explained query, run first in the
+	// same session.
+	setup []string
+	// Why the query was stopped before it finished, "cancelled" or "timed
+	// out", the result is then the estimated plan.
+	cancelled string
+	elapsed   time.Duration
 }
 
 var defaultPgexDir = "_pgex"
@@ -105,9 +121,12 @@ func loadQueryRun(pgexFile string) (QueryRun, error) {
 
 	settingsStrings := strings.Split(settingsContent, "\n")
 
+	var queryRun QueryRun
 	settings := make([]Setting, 0, len(settingsStrings))
 	for _, settingStr := range settingsStrings {
-		if ansi.StringWidth(strings.Trim(settingStr, " ")) > 0 {
+		if attribute, ok := strings.CutPrefix(settingStr, pgexAttributePrefix); ok {
+			queryRun.setPgexAttribute(attribute)
+		} else if ansi.StringWidth(strings.Trim(settingStr, " ")) > 0 {
 			settings = append(settings, SettingUnmarshal(settingStr))
 		}
 	}
@@ -119,7 +138,115 @@ func loadQueryRun(pgexFile string) (QueryRun, error) {
 
 	_, file := path.Split(pgexFile)
 
-	return QueryRun{query: sql, result: plan, pgexPointer: file, settings: settings}, nil
+	queryRun.query = sql
+	queryRun.result = plan
+	queryRun.pgexPointer = file
+	queryRun.settings = settings
+	return queryRun, nil
+}
+
+// Lines of the settings section of a pgex file starting with the attribute
+// prefix record how the query was run, e.g. "# transaction: rolled back".
+var pgexAttributePrefix = "# "
+
+func (q *QueryRun) setPgexAttribute(attribute string) {
+	key, value, _ := strings.Cut(attribute, ": ")
+	switch key {
+	case "transaction":
+		q.rolledBack = value == "rolled back"
+	case "explain":
+		q.options = ParseExplainOptions(value)
+		q.optionsRecorded = true
+	case "plan":
+		q.options.GenericPlan = value == "generic"
+	case "cancelled":
+		q.cancelled = value
+	case "elapsed":
+		q.elapsed, _ = time.ParseDuration(value)
+	case "setup":
+		if statement, err := strconv.Unquote(value); err == nil {
+			q.setup = append(q.setup, statement)
+		}
+	}
+
+	if number, ok := strings.CutPrefix(key, "param $"); ok {
+		n, err := strconv.Atoi(number)
+		unquoted, unquoteErr := strconv.Unquote(value)
+		if err == nil && unquoteErr == nil {
+			q.params = setParam(q.params, QueryParam{number: n, value: unquoted})
+		}
+	}
+}
+
+func (q QueryRun) pgexAttributes() []string {
+	attributes := []string{}
+	if q.optionsRecorded {
+		attributes = append(attributes, "explain: "+q.options.String())
+	}
+	if q.cancelled != "" {
+		attributes = append(attributes, "cancelled: "+q.cancelled)
+		attributes = append(attributes, "elapsed: "+q.elapsed.Round(time.Millisecond).String())
+	} else if q.rolledBack {
+		attributes = append(attributes, "transaction: rolled back")
+	} else if q.changesData(q.analyzes()) {
+		attributes = append(attributes, "transaction: committed")
+	}
+	for _, statement := range q.setup {
+		attributes = append(attributes, "setup: "+strconv.Quote(statement))
+	}
+	if !q.options.GenericPlan {
+		for _, param := range q.params {
+			attributes = append(attributes, fmt.Sprintf("param $%d: %s", param.number, strconv.Quote(param.value)))
+		}
+	}
+	return attributes
+}
+
+// ModifiesData reports whether running the statement might change the
+// database. Only SELECT without INTO, WITH without INSERT, UPDATE, DELETE or
+// MERGE in its CTEs, VALUES, TABLE, SET and SHOW are known to only read, any
+// other statement, e.g. TRUNCATE, CALL or EXECUTE of a prepared statement, is
+// taken as changing data. Functions with side effects can't be told apart.
+func ModifiesData(sql string) bool {
+	words := sqlsplit.Words(sql)
+	if len(words) == 0 {
+		return false
+	}
+
+	switch words[0] {
+	case "VALUES", "TABLE", "SET", "SHOW":
+		return false
+	case "SELECT":
+		// SELECT INTO creates a table.
+		return slices.Contains(words, "INTO")
+	case "WITH":
+		for i, word := range words {
+			switch word {
+			case "INSERT", "DELETE", "MERGE", "INTO":
+				return true
+			case "UPDATE":
+				// FOR UPDATE and FOR NO KEY UPDATE lock rows without changing them.
+				if words[i-1] == "FOR" || words[i-1] == "KEY" {
+					continue
+				}
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// changesData reports whether the run changes the database, either through
+// its setup statements, which are always run, or through the query when it is
+// analyzed.
+func (q QueryRun) changesData(analyze bool) bool {
+	for _, statement := range q.setup {
+		if ModifiesData(statement) {
+			return true
+		}
+	}
+	return analyze && ModifiesData(q.query)
 }
 
 func getQueryRunEntries() ([]string, error) {
@@ -141,27 +268,22 @@ func getQueryRunEntries() ([]string, error) {
 	return pgexFiles, nil
 }
 
-func NewQueryRun(filename string) QueryRun {
-	if _, err := os.Stat(filename); err == nil {
-		body, err := os.ReadFile(filename)
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		sqls := sqlsplit.Split(string(body))
-
-		if len(sqls) != 1 {
-			log.Fatal("too many sql statements in provided file")
-		} else {
-			return QueryRun{
-				query:            sqls[0],
-				originalFilename: filename,
-			}
-		}
-	} else {
-		log.Fatal(err)
+// NewQueryRun reads the statement of the sql file to explain, the last one
+// when statement is 0, the statements before it are run as its setup.
+func NewQueryRun(filename string, statement int) (QueryRun, error) {
+	statements, err := ReadStatements(filename)
+	if err != nil {
+		return QueryRun{}, err
 	}
-	return QueryRun{}
+	setup, query, err := SelectStatement(statements, statement)
+	if err != nil {
+		return QueryRun{}, fmt.Errorf("%s: %w", filename, err)
+	}
+	return QueryRun{
+		query:            query,
+		setup:            setup,
+		originalFilename: filename,
+	}, nil
 }
 
 func (q *QueryRun) SetResult(result string) {
@@ -202,6 +324,11 @@ var sqlDivider = "---------------- SETTINGS ABOVE / SQL BELOW ----------------"
 
 func (q QueryRun) pgexFileContent() string {
 	var buf strings.Builder
+	for _, attribute := range q.pgexAttributes() {
+		buf.WriteString(pgexAttributePrefix)
+		buf.WriteString(attribute)
+		buf.WriteString("\n")
+	}
 	for _, setting := range q.settings {
 		buf.WriteString(setting.Marshal())
 		buf.WriteString("\n")
@@ -218,21 +345,28 @@ func (q QueryRun) pgexFileContent() string {
 	return buf.String()
 }
 
-func (q QueryRun) WithExplain() string {
-	explainSegment := `explain (
-		format json
-	) `
-
-	return explainSegment + q.query
+// analyzes reports whether the query was run by EXPLAIN ANALYZE.
+func (q QueryRun) analyzes() bool {
+	if !q.optionsRecorded {
+		return !q.options.GenericPlan
+	}
+	return q.options.Analyze
 }
 
-func (q QueryRun) WithExplainAnalyze() string {
-	explainSegment := `explain (
-		settings,
-		format json,
-		buffers,
-		analyze
-	) `
+// WithExplain explains the query without running it.
+func (q QueryRun) WithExplain() (string, error) {
+	target, err := q.explainTarget()
+	if err != nil {
+		return "", err
+	}
+	return q.options.estimate().Statement() + target, nil
+}
 
-	return explainSegment + q.query
+// WithExplainAnalyze explains the query with the options of the run.
+func (q QueryRun) WithExplainAnalyze() (string, error) {
+	target, err := q.explainTarget()
+	if err != nil {
+		return "", err
+	}
+	return q.options.Statement() + target, nil
 }
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestModifiesData(t *testing.T) {
+	modifying := []string{
+		"update orders set status = 'closed' where id = 1",
+		"DELETE FROM orders",
+		"insert into orders (id) values (1) on conflict do nothing",
+		"merge into orders o using staged s on o.id = s.id when matched then delete",
+		"with moved as (delete from orders returning *) select count(*) from moved",
+		"select * into orders_copy from orders",
+		"create table orders_copy as select * from orders",
+		"execute close_orders(1)",
+		"/* report */ update orders set status = 'closed'",
+		"truncate orders",
+		"drop table orders",
+		"call close_orders(1)",
+		"alter table orders add column note text",
+		"copy orders from '/tmp/orders.csv'",
+		"do $$ begin delete from orders; end $$",
+		"refresh materialized view order_totals",
+		"vacuum orders",
+		"with stale as (select id from orders) update orders set status = 'closed' from stale",
+	}
+	for _, sql := range modifying {
+		assert.True(t, ModifiesData(sql), sql)
+	}
+
+	reading := []string{
+		"select * from orders where status = 'update'",
+		"select * from orders for update",
+		"select * from orders for no key update skip locked",
+		"select \"delete\" from audit -- insert into audit\n",
+		"select $$ delete from orders $$",
+		"select e'it\\'s an update' from orders",
+		"with recent as (select * from orders) select * from recent",
+		"select update from t",
+		"values (1), (2)",
+		"table orders",
+		"set work_mem = '64MB'",
+		"show work_mem",
+		"",
+	}
+	for _, sql := range reading {
+		assert.False(t, ModifiesData(sql), sql)
+	}
+}
+
+func TestPgexRolledBack(t *testing.T) {
+	queryRun := QueryRun{
+		query:      "update orders set status = 'closed'",
+		result:     "[]",
+		settings:   []Setting{{name: "work_mem", setting: "4MB"}},
+		rolledBack: true,
+	}
+	content := queryRun.pgexFileContent()
+	assert.Contains(t, content, "# transaction: rolled back\nwork_mem=4MB\n")
+
+	pgexFile := filepath.Join(t.TempDir(), "20241206112818_close.pgex")
+	if err := os.WriteFile(pgexFile, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadQueryRun(pgexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, loaded.rolledBack)
+	assert.Equal(t, []Setting{{name: "work_mem", setting: "4MB"}}, loaded.settings)
+
+	queryRun.rolledBack = false
+	assert.Contains(t, queryRun.pgexFileContent(), "# transaction: committed\n")
+	queryRun.query = "select 1"
+	assert.NotContains(t, queryRun.pgexFileContent(), "# transaction")
+}
+
+func TestPgexCancelled(t *testing.T) {
+	queryRun := QueryRun{
+		query:     "select pg_sleep(60)",
+		result:    "[]",
+		cancelled: "cancelled",
+		elapsed:   4567 * time.Millisecond,
+	}
+	content := queryRun.pgexFileContent()
+	assert.Contains(t, content, "# cancelled: cancelled\n# elapsed: 4.567s\n")
+
+	pgexFile := filepath.Join(t.TempDir(), "20241206112818_sleep.pgex")
+	if err := os.WriteFile(pgexFile, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadQueryRun(pgexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "cancelled", loaded.cancelled)
+	assert.Equal(t, 4567*time.Millisecond, loaded.elapsed)
+	assert.Empty(t, loaded.settings)
+}
+
+func TestCancelReason(t *testing.T) {
+	assert.Equal(t, "cancelled", cancelReason(context.Canceled))
+	assert.Equal(t, "cancelled", cancelReason(fmt.Errorf("explain: %w", &pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"})))
+	assert.Equal(t, "timed out", cancelReason(&pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"}))
+	assert.Equal(t, "", cancelReason(errors.New("relation \"orders\" does not exist")))
+}
+
+func TestPgexExplainOptions(t *testing.T) {
+	options := DefaultExplainOptions()
+	options.WAL = true
+	queryRun := QueryRun{
+		query:           "select 1",
+		result:          "[]",
+		options:         options,
+		optionsRecorded: true,
+	}
+	content := queryRun.pgexFileContent()
+	assert.Contains(t, content, "# explain: analyze, buffers, timing, wal, costs, summary\n")
+
+	pgexFile := filepath.Join(t.TempDir(), "20241206112818_one.pgex")
+	if err := os.WriteFile(pgexFile, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadQueryRun(pgexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, loaded.optionsRecorded)
+	assert.Equal(t, options, loaded.options)
+
+	// Only analyzed data-modifying statements change the database.
+	queryRun.query = "delete from orders"
+	queryRun.options.Analyze = false
+	assert.NotContains(t, queryRun.pgexFileContent(), "# transaction")
+
+	queryRun.optionsRecorded = false
+	assert.NotContains(t, queryRun.pgexFileContent(), "# explain")
+}
+
+func TestPgexSetup(t *testing.T) {
+	queryRun := QueryRun{
+		query:  "select count(*) from todays_orders;",
+		result: "[]",
+		setup:  []string{"set work_mem = '64MB';", "create temp table todays_orders as\n  select * from orders;"},
+	}
+	content := queryRun.pgexFileContent()
+	assert.Contains(t, content, "# setup: \"set work_mem = '64MB';\"\n# setup: \"create temp table todays_orders as\\n  select * from orders;\"\n")
+
+	pgexFile := filepath.Join(t.TempDir(), "20241206112818_scratch.pgex")
+	if err := os.WriteFile(pgexFile, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadQueryRun(pgexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, queryRun.setup, loaded.setup)
+	assert.Empty(t, loaded.settings)
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+	"github.com/muesli/termenv"
+)
+
+type RenderOptions struct {
+	Stat    StatView
+	NoColor bool
+	Width   int
+	Bars    bool
+}
+
+var statViewNames = map[string]StatView{
+	"none":      DisplayNothing,
+	"rows":      DisplayRows,
+	"time":      DisplayTime,
+	"exclusive": DisplayExclusive,
+	"buffers":   DisplayBuffers,
+	"wal":       DisplayWAL,
+	"cost":      DisplayCost,
+}
+
+// analyzedStatViews are the stats only available for plans run with ANALYZE.
+var analyzedStatViews = []StatView{DisplayTime, DisplayExclusive, DisplayBuffers, DisplayWAL}
+
+func ParseStatView(name string) (StatView, error) {
+	statView, ok := statViewNames[strings.ToLower(name)]
+	if !ok {
+		return DisplayNothing, fmt.Errorf("unknown stat '%s', expected one of rows, time, exclusive, buffers, wal, cost or none", name)
+	}
+	return statView, nil
+}
+
+// RenderPlan prints the plan tree with the status line and the chosen stats
+// the way the interactive view shows them, without a cursor.
+func RenderPlan(queryRun QueryRun, source Source, options RenderOptions) (string, error) {
+	explainPlan, err := Convert(queryRun.result)
+	if err != nil {
+		return "", err
+	}
+
+	if !explainPlan.analyzed && slices.Contains(analyzedStatViews, options.Stat) {
+		return "", fmt.Errorf("stat '%s' requires a plan explained with ANALYZE", strings.ToLower(options.Stat.String()))
+	}
+
+	if options.NoColor {
+		lipgloss.SetColorProfile(termenv.Ascii)
+	}
+
+	m := InitModel(source)
+	m.queryRun = queryRun
+	m.ctx.Width = options.Width
+	m.ctx.StatDisplay = options.Stat
+	m.ctx.DisplayRelations = true
+	m.ctx.DisplayBars = options.Bars
+	m.ctx.Analyzed = explainPlan.analyzed
+	m.ctx.Costs = explainPlan.costs
+	// Render every node in the normal style, there is no cursor to follow.
+	m.ctx.Cursor = -1
+	m.ctx.CursorStyle = m.ctx.NormalStyle
+	m.ctx.ChildCursorStyle = m.ctx.NormalStyle
+	m.UpdateModel(explainPlan)
+
+	var buf strings.Builder
+
+	statusLine := m.StatusLine.View(m)
+	buf.WriteString(statusLine)
+	buf.WriteString(HeadersView(m.ctx, m.ctx.Width-ansi.StringWidth(statusLine)-1))
+	buf.WriteString("\n")
+
+	for i, node := range m.DisplayNodes {
+		buf.WriteString(node.View(i, m.ctx))
+	}
+
+	return buf.String(), nil
+}
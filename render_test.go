@@ -0,0 +1,60 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderPlan(t *testing.T) {
+	data, err := os.ReadFile("./testdata/node_details.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	queryRun := QueryRun{result: string(data)}
+	rendered, err := RenderPlan(queryRun, Source{sourceType: SOURCE_STDIN}, RenderOptions{Stat: DisplayTime, NoColor: true, Width: 90})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+	assert.Equal(t, 7, len(lines))
+	assert.Contains(t, lines[0], "Time: 236.771ms")
+	assert.Contains(t, lines[0], "Startup")
+	assert.Contains(t, lines[3], "Bitmap Heap Scan orders o")
+	assert.True(t, strings.HasSuffix(lines[3], "8.10          70.44"))
+	assert.NotContains(t, rendered, "\x1b[")
+}
+
+func TestRenderPlanRequiresAnalyze(t *testing.T) {
+	data, err := os.ReadFile("./testdata/verbose_selfjoin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = RenderPlan(QueryRun{result: string(data)}, Source{sourceType: SOURCE_STDIN}, RenderOptions{Stat: DisplayBuffers, Width: 90})
+	assert.EqualError(t, err, "stat 'buffers' requires a plan explained with ANALYZE")
+
+	_, err = ParseStatView("bogus")
+	assert.Error(t, err)
+	statView, err := ParseStatView("Cost")
+	assert.NoError(t, err)
+	assert.Equal(t, DisplayCost, statView)
+}
+
+func TestRenderPlanBars(t *testing.T) {
+	data, err := os.ReadFile("./testdata/verbose_selfjoin.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rendered, err := RenderPlan(QueryRun{result: string(data)}, Source{sourceType: SOURCE_STDIN}, RenderOptions{Stat: DisplayCost, NoColor: true, Width: 90, Bars: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(rendered, "\n"), "\n")
+	assert.True(t, strings.HasSuffix(lines[0], "Total            "))
+	assert.True(t, strings.HasSuffix(lines[1], "64.12 ██████████"))
+	assert.True(t, strings.HasSuffix(lines[2], "20.70 ███▎      "))
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/x/ansi"
@@ -38,6 +39,34 @@ func SettingCompare(a, b Setting) int {
 	return a.FindPosition() - b.FindPosition()
 }
 
+// MergeSettings adds the non-default settings reported in the plan to the
+// settings the query was run with.
+func MergeSettings(runSettings, planSettings []Setting) []Setting {
+	merged := slices.Clone(runSettings)
+	for _, planSetting := range planSettings {
+		if FindSetting(runSettings, planSetting.name) == nil {
+			merged = append(merged, planSetting)
+		}
+	}
+	return merged
+}
+
+func FindSetting(settings []Setting, name string) *Setting {
+	for i := range settings {
+		if settings[i].name == name {
+			return &settings[i]
+		}
+	}
+	return nil
+}
+
+// Differs reports whether the setting has another value in the compared
+// settings, settings missing from them are not considered different.
+func (setting Setting) Differs(settings []Setting) bool {
+	other := FindSetting(settings, setting.name)
+	return other != nil && other.setting != setting.setting
+}
+
 func (setting Setting) Sql() string {
 	return fmt.Sprintf("SET %s = '%s'", setting.name, setting.setting)
 }
@@ -0,0 +1,128 @@
+package sqlsplit
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Words returns the upper cased keywords and unquoted identifiers of sql in
+// order, skipping string constants, quoted identifiers and comments.
+func Words(sql string) []string {
+	words, _ := scan(sql)
+	return words
+}
+
+// Placeholders returns the numbers of the $n parameters of sql in order of
+// appearance, a parameter used more than once is listed once.
+func Placeholders(sql string) []int {
+	_, placeholders := scan(sql)
+	return placeholders
+}
+
+func scan(sql string) (words []string, placeholders []int) {
+	pos := 0
+	for pos < len(sql) {
+		r, width := utf8.DecodeRuneInString(sql[pos:])
+
+		switch {
+		case (r == 'e' || r == 'E') && strings.HasPrefix(sql[pos+width:], "'"):
+			pos = skipQuoted(sql, pos+width+1, '\'', true)
+		case r == '\'':
+			pos = skipQuoted(sql, pos+width, '\'', false)
+		case r == '"':
+			pos = skipQuoted(sql, pos+width, '"', false)
+		case r == '$':
+			tag, ok := readDollarTag(sql[pos+width:])
+			if !ok {
+				pos += width
+				number := 0
+				for pos < len(sql) && '0' <= sql[pos] && sql[pos] <= '9' {
+					number = number*10 + int(sql[pos]-'0')
+					pos++
+				}
+				if number > 0 && !slices.Contains(placeholders, number) {
+					placeholders = append(placeholders, number)
+				}
+				continue
+			}
+			closing := "$" + tag + "$"
+			end := strings.Index(sql[pos+len(closing):], closing)
+			if end < 0 {
+				return words, placeholders
+			}
+			pos += len(closing) + end + len(closing)
+		case strings.HasPrefix(sql[pos:], "--"):
+			end := strings.IndexAny(sql[pos:], "\n\r")
+			if end < 0 {
+				return words, placeholders
+			}
+			pos += end
+		case strings.HasPrefix(sql[pos:], "/*"):
+			pos = skipComment(sql, pos+2)
+		case unicode.IsLetter(r) || r == '_':
+			start := pos
+			for pos < len(sql) {
+				r, width := utf8.DecodeRuneInString(sql[pos:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '$' {
+					break
+				}
+				pos += width
+			}
+			words = append(words, strings.ToUpper(sql[start:pos]))
+		case unicode.IsDigit(r):
+			// Skip numbers so that 1e5 isn't read as a word.
+			for pos < len(sql) {
+				r, width := utf8.DecodeRuneInString(sql[pos:])
+				if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '.' {
+					break
+				}
+				pos += width
+			}
+		default:
+			pos += width
+		}
+	}
+	return words, placeholders
+}
+
+// skipQuoted returns the position after the closing quote, a doubled quote
+// doesn't close the constant.
+func skipQuoted(sql string, pos int, quote byte, backslashEscapes bool) int {
+	for pos < len(sql) {
+		switch {
+		case backslashEscapes && sql[pos] == '\\':
+			pos += 2
+		case sql[pos] == quote && pos+1 < len(sql) && sql[pos+1] == quote:
+			pos += 2
+		case sql[pos] == quote:
+			return pos + 1
+		default:
+			pos++
+		}
+	}
+	return len(sql)
+}
+
+// skipComment returns the position after the end of a possibly nested
+// multiline comment.
+func skipComment(sql string, pos int) int {
+	nested := 0
+	for pos < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[pos:], "/*"):
+			nested++
+			pos += 2
+		case strings.HasPrefix(sql[pos:], "*/"):
+			if nested == 0 {
+				return pos + 2
+			}
+			nested--
+			pos += 2
+		default:
+			pos++
+		}
+	}
+	return len(sql)
+}
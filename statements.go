@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"pg-explain/sqlsplit"
+	"strconv"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// ReadStatements splits the sql file into its statements.
+func ReadStatements(filename string) ([]string, error) {
+	body, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return sqlsplit.Split(string(body)), nil
+}
+
+// SelectStatement returns the statements before the one to explain and the
+// statement itself, numbered from 1, the last one when the number is 0.
+func SelectStatement(statements []string, number int) ([]string, string, error) {
+	if number == 0 {
+		number = len(statements)
+	}
+	if number < 1 || number > len(statements) {
+		return nil, "", fmt.Errorf("no statement %d, the file has %d statements", number, len(statements))
+	}
+	return statements[:number-1], statements[number-1], nil
+}
+
+// statementSummary is the first line of the statement that isn't a comment,
+// shortened to fit the width.
+func statementSummary(statement string, width int) string {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return ansi.Truncate(line, width, "…")
+		}
+	}
+	return ansi.Truncate(strings.TrimSpace(statement), width, "…")
+}
+
+// PickStatement lists the statements and asks for the number of the one to
+// explain, the last one when no number is given.
+func PickStatement(statements []string, in io.Reader, out io.Writer) (int, error) {
+	for i, statement := range statements {
+		fmt.Fprintf(out, "%3d  %s\n", i+1, statementSummary(statement, 72))
+	}
+	fmt.Fprintf(out, "Statement to explain [%d]: ", len(statements))
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, err
+	}
+	answer = strings.TrimSpace(answer)
+	if answer == "" {
+		return len(statements), nil
+	}
+	number, err := strconv.Atoi(answer)
+	if err != nil || number < 1 || number > len(statements) {
+		return 0, fmt.Errorf("'%s' isn't the number of a statement between 1 and %d", answer, len(statements))
+	}
+	return number, nil
+}
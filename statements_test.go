@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var scratchStatements = []string{
+	"set work_mem = '64MB';",
+	"-- orders of the day\ncreate temp table todays_orders as select * from orders where created_at > now() - interval '1 day';",
+	"select count(*) from todays_orders;",
+}
+
+func TestSelectStatement(t *testing.T) {
+	setup, query, err := SelectStatement(scratchStatements, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, scratchStatements[:2], setup)
+	assert.Equal(t, "select count(*) from todays_orders;", query)
+
+	setup, query, err = SelectStatement(scratchStatements, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, scratchStatements[:1], setup)
+	assert.Equal(t, scratchStatements[1], query)
+
+	_, _, err = SelectStatement(scratchStatements, 4)
+	assert.EqualError(t, err, "no statement 4, the file has 3 statements")
+}
+
+func TestPickStatement(t *testing.T) {
+	var out bytes.Buffer
+	number, err := PickStatement(scratchStatements, strings.NewReader("2\n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, number)
+	assert.Equal(t, "  1  set work_mem = '64MB';\n"+
+		"  2  create temp table todays_orders as select * from orders where created_a…\n"+
+		"  3  select count(*) from todays_orders;\n"+
+		"Statement to explain [3]: ", out.String())
+
+	number, err = PickStatement(scratchStatements, strings.NewReader("\n"), &out)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, number)
+
+	_, err = PickStatement(scratchStatements, strings.NewReader("7\n"), &out)
+	assert.EqualError(t, err, "'7' isn't the number of a statement between 1 and 3")
+}
+
+func TestNewQueryRunSetup(t *testing.T) {
+	sqlFile := filepath.Join(t.TempDir(), "scratch.sql")
+	if err := os.WriteFile(sqlFile, []byte(strings.Join(scratchStatements, "\n")+"\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	queryRun, err := NewQueryRun(sqlFile, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "select count(*) from todays_orders;", queryRun.query)
+	assert.Equal(t, scratchStatements[:2], queryRun.SetupStatements())
+	assert.Equal(t, strings.Join(scratchStatements[:2], "\n")+"\n\nselect count(*) from todays_orders;", queryRun.DisplaySql())
+	// Creating the temp table changes the database.
+	assert.True(t, queryRun.changesData(false))
+
+	queryRun, err = NewQueryRun(sqlFile, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "set work_mem = '64MB';", queryRun.query)
+	assert.Empty(t, queryRun.setup)
+	assert.False(t, queryRun.changesData(true))
+
+	_, err = NewQueryRun(sqlFile, 4)
+	assert.ErrorContains(t, err, "no statement 4, the file has 3 statements")
+	_, err = NewQueryRun(filepath.Join(t.TempDir(), "missing.sql"), 0)
+	assert.Error(t, err)
+}
@@ -4,22 +4,41 @@ import (
 	"fmt"
 	"strings"
 	"time"
+
+	"github.com/charmbracelet/x/ansi"
 )
 
 type StatusLine struct {
 	ExecutionTime float64
+	PlanningTime  float64
+	TriggerTime   float64
+	JITTime       float64
+	HasTriggers   bool
+	HasJIT        bool
 	TotalBuffers  int
+	TempBlocks    int
+	IOTime        float64
 	TotalRows     int
 }
 
 func NewStatusLine(explainPlan ExplainPlan) StatusLine {
 	return StatusLine{
 		ExecutionTime: explainPlan.executionTime,
+		PlanningTime:  explainPlan.planningTime,
+		TriggerTime:   explainPlan.TotalTriggerTime(),
+		JITTime:       explainPlan.jit.Timing["Total"],
+		HasTriggers:   len(explainPlan.triggers) > 0,
+		HasJIT:        explainPlan.jit.Functions > 0,
 		TotalBuffers:  explainPlan.TotalBuffers(),
+		TempBlocks:    explainPlan.TotalTempBlocks(),
+		IOTime:        explainPlan.TotalIOTime(),
 		TotalRows:     explainPlan.TotalRows(),
 	}
 }
 
+// Space kept free to the right of the status line for the stat headers.
+var statusLineHeaderSpace = 40
+
 func (s StatusLine) View(m Model) string {
 	styles := m.ctx.StatusStyles
 
@@ -27,33 +46,64 @@ func (s StatusLine) View(m Model) string {
 		return ""
 	}
 
-	var buf strings.Builder
-
-	buf.WriteString(styles.AltNormal.Render("  "))
-	buf.WriteString(styles.Normal.Render(""))
-	buf.WriteString(styles.Normal.Render(" Time:"))
-	buf.WriteString(styles.Value.Render(" %.3fms "))
-	buf.WriteString(styles.AltNormal.Render(""))
-	buf.WriteString(styles.Normal.Render(""))
-	buf.WriteString(styles.Normal.Render(" Buffers:"))
-	buf.WriteString(styles.Value.Render(" %s "))
-	buf.WriteString(styles.AltNormal.Render(""))
-	buf.WriteString(styles.Normal.Render(""))
-	buf.WriteString(styles.Normal.Render(" Rows:"))
-	buf.WriteString(styles.Value.Render(" %s "))
-	buf.WriteString(styles.AltNormal.Render(" "))
-
 	var executionTime float64
 	if m.loading {
 		executionTime = float64(int64(m.stopwatch.Elapsed() / time.Millisecond))
 	} else {
 		executionTime = s.ExecutionTime
 	}
-	result := fmt.Sprintf(buf.String(),
-		executionTime,
-		formatUnderscores(s.TotalBuffers),
-		formatUnderscores(s.TotalRows),
-	)
 
-	return result
+	segment := func(label string, value string) string {
+		var buf strings.Builder
+		buf.WriteString(styles.Normal.Render(""))
+		buf.WriteString(styles.Normal.Render(fmt.Sprintf(" %s:", label)))
+		buf.WriteString(styles.Value.Render(fmt.Sprintf(" %s ", value)))
+		buf.WriteString(styles.AltNormal.Render(""))
+		return buf.String()
+	}
+
+	timeSegment := segment("Time", fmt.Sprintf("%.3fms", executionTime))
+	extraSegments := []string{}
+	if s.PlanningTime > 0 {
+		extraSegments = append(extraSegments, segment("Planning", fmt.Sprintf("%.3fms", s.PlanningTime)))
+	}
+	if s.HasTriggers {
+		extraSegments = append(extraSegments, segment("Triggers", fmt.Sprintf("%.3fms", s.TriggerTime)))
+	}
+	if s.HasJIT {
+		extraSegments = append(extraSegments, segment("JIT", fmt.Sprintf("%.3fms", s.JITTime)))
+	}
+	if s.TempBlocks > 0 {
+		extraSegments = append(extraSegments, segment("Temp", formatUnderscores(s.TempBlocks)))
+	}
+	if s.IOTime > 0 {
+		extraSegments = append(extraSegments, segment("I/O", fmt.Sprintf("%.3fms", s.IOTime)))
+	}
+	tailSegments := segment("Buffers", formatUnderscores(s.TotalBuffers)) +
+		segment("Rows", formatUnderscores(s.TotalRows))
+	if m.queryRun.cancelled != "" {
+		cancelled := fmt.Sprintf(" %s after %s ", strings.ToUpper(m.queryRun.cancelled[:1])+m.queryRun.cancelled[1:], m.queryRun.elapsed.Round(time.Millisecond))
+		tailSegments += styles.Normal.Render("") + styles.Value.Render(cancelled) + styles.AltNormal.Render("")
+	} else if m.queryRun.rolledBack {
+		tailSegments += styles.Normal.Render("") + styles.Value.Render(" Rolled Back ") + styles.AltNormal.Render("")
+	}
+
+	var buf strings.Builder
+	buf.WriteString(styles.AltNormal.Render("  "))
+	buf.WriteString(timeSegment)
+
+	// Only show the extra timings that leave room for the headers.
+	width := 3 + ansi.StringWidth(timeSegment+tailSegments)
+	for _, extra := range extraSegments {
+		width += ansi.StringWidth(extra)
+		if width+statusLineHeaderSpace > m.ctx.Width {
+			break
+		}
+		buf.WriteString(extra)
+	}
+
+	buf.WriteString(tailSegments)
+	buf.WriteString(styles.AltNormal.Render(" "))
+
+	return buf.String()
 }
@@ -1,7 +1,10 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"path"
 	"slices"
 	"strings"
@@ -15,6 +18,7 @@ import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/stopwatch"
+	"github.com/charmbracelet/bubbles/textinput"
 )
 
 // keyMap defines a set of keybindings. To work for help it must satisfy
@@ -31,11 +35,34 @@ type keyMap struct {
 	Quit               key.Binding
 	JoinView           key.Binding
 	ToggleDisplaySql   key.Binding
+	ToggleDisplayQuery key.Binding
 	NextStatDisplay    key.Binding
 	PrevStatDisplay    key.Binding
+	NextBuffersDisplay key.Binding
+	ToggleFold         key.Binding
+	Parent             key.Binding
+	FirstChild         key.Binding
+	NextSibling        key.Binding
+	PrevSibling        key.Binding
+	Top                key.Binding
+	Bottom             key.Binding
+	WorstTime          key.Binding
+	WorstEstimate      key.Binding
+	MostRead           key.Binding
+	Search             key.Binding
+	NextMatch          key.Binding
+	PrevMatch          key.Binding
+	ToggleFilter       key.Binding
+	FoldBelowDepth     key.Binding
+	UnfoldAll          key.Binding
 	ToggleParallel     key.Binding
 	ToggleNumbers      key.Binding
 	ToggleRelations    key.Binding
+	ToggleIcicle       key.Binding
+	ToggleBars         key.Binding
+	CancelQuery        key.Binding
+	ToggleOptions      key.Binding
+	SaveOptions        key.Binding
 	ReExecute          key.Binding
 	PrevQueryRun       key.Binding
 	NextQueryRun       key.Binding
@@ -59,8 +86,10 @@ func (k keyMap) SqlShortHelp() []key.Binding {
 // key.Map interface.
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
-		{k.Up, k.Down, k.ToggleParallel, k.ToggleNumbers, k.ToggleDisplaySql, k.ToggleRelations, k.ReExecute}, // first column
-		{k.NextStatDisplay, k.PrevStatDisplay, k.SettingsUp, k.SettingsDown, k.SettingIncrement, k.SettingDecrement},
+		{k.Up, k.Down, k.ToggleFold, k.FoldBelowDepth, k.UnfoldAll, k.ToggleParallel, k.ToggleNumbers, k.ToggleDisplaySql, k.ToggleDisplayQuery, k.ToggleRelations, k.ToggleIcicle, k.ReExecute, k.CancelQuery}, // first column
+		{k.NextStatDisplay, k.PrevStatDisplay, k.NextBuffersDisplay, k.ToggleBars, k.SettingsUp, k.SettingsDown, k.SettingIncrement, k.SettingDecrement, k.ToggleOptions, k.SaveOptions},
+		{k.Parent, k.FirstChild, k.NextSibling, k.PrevSibling, k.Top, k.Bottom, k.WorstTime, k.WorstEstimate, k.MostRead},
+		{k.Search, k.NextMatch, k.PrevMatch, k.ToggleFilter},
 		{k.PrevQueryRun, k.NextQueryRun, k.Help, k.Quit}, // second column
 	}
 }
@@ -98,6 +127,78 @@ var keys = keyMap{
 		key.WithKeys("J"),
 		key.WithHelp("J", "Join"),
 	),
+	Parent: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/h", "Parent"),
+	),
+	FirstChild: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("→/l", "First Child"),
+	),
+	NextSibling: key.NewBinding(
+		key.WithKeys(">"),
+		key.WithHelp(">", "Next Sibling"),
+	),
+	PrevSibling: key.NewBinding(
+		key.WithKeys("<"),
+		key.WithHelp("<", "Prev Sibling"),
+	),
+	Top: key.NewBinding(
+		key.WithKeys("g", "home"),
+		key.WithHelp("g", "Top"),
+	),
+	Bottom: key.NewBinding(
+		key.WithKeys("G", "end"),
+		key.WithHelp("G", "Bottom"),
+	),
+	WorstTime: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "Highest Self Time"),
+	),
+	WorstEstimate: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "Worst Misestimate"),
+	),
+	MostRead: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "Most Buffers Read"),
+	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "Search"),
+	),
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "Next Match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "Prev Match"),
+	),
+	ToggleFilter: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "Filter Matches"),
+	),
+	ToggleFold: key.NewBinding(
+		key.WithKeys("f"),
+		key.WithHelp("f", "Fold/Unfold Node"),
+	),
+	FoldBelowDepth: key.NewBinding(
+		key.WithKeys("1", "2", "3", "4", "5", "6", "7", "8", "9"),
+		key.WithHelp("1-9", "Fold Below Depth"),
+	),
+	UnfoldAll: key.NewBinding(
+		key.WithKeys("0"),
+		key.WithHelp("0", "Unfold All"),
+	),
+	NextBuffersDisplay: key.NewBinding(
+		key.WithKeys("b"),
+		key.WithHelp("b", "Next Buffers Columns"),
+	),
+	ToggleBars: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "Toggle Bars"),
+	),
 	NextStatDisplay: key.NewBinding(
 		key.WithKeys("]"),
 		key.WithHelp("]", "Next Stat Display"),
@@ -111,17 +212,37 @@ var keys = keyMap{
 		key.WithHelp("P", "Toggle Parallel"),
 	),
 	ToggleNumbers: key.NewBinding(
-		key.WithKeys("N"),
-		key.WithHelp("N", "Toggle Numbers"),
+		key.WithKeys("#"),
+		key.WithHelp("#", "Toggle Numbers"),
 	),
 	ToggleRelations: key.NewBinding(
 		key.WithKeys("R"),
 		key.WithHelp("R", "Toggle Relations"),
 	),
+	ToggleIcicle: key.NewBinding(
+		key.WithKeys("i"),
+		key.WithHelp("i", "Toggle Icicle Chart"),
+	),
 	ToggleDisplaySql: key.NewBinding(
 		key.WithKeys("D"),
 		key.WithHelp("D", "Toggle Display SQL"),
 	),
+	ToggleDisplayQuery: key.NewBinding(
+		key.WithKeys("Q"),
+		key.WithHelp("Q", "Toggle Query Details"),
+	),
+	ToggleOptions: key.NewBinding(
+		key.WithKeys("o"),
+		key.WithHelp("o", "Toggle Explain Options"),
+	),
+	SaveOptions: key.NewBinding(
+		key.WithKeys("W"),
+		key.WithHelp("W", "Save Explain Options"),
+	),
+	CancelQuery: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "Cancel Query"),
+	),
 	ReExecute: key.NewBinding(
 		key.WithKeys("X"),
 		key.WithHelp("X", "ReExecute Query"),
@@ -165,13 +286,16 @@ type Model struct {
 	help                 help.Model
 	sqlHelp              help.Model
 	nodes                []PlanNode
+	explainPlan          ExplainPlan
 	ctx                  ProgramContext
 	DisplayNodes         []PlanNode
 	StatusLine           StatusLine
 	detailsViewport      Section
+	queryViewport        Section
 	sqlViewport          Section
 	thisSettingsViewport Section
 	nextSettingsViewport Section
+	planSettingsViewport Section
 	source               Source
 	originalSource       Source
 	queryRun             QueryRun
@@ -183,6 +307,16 @@ type Model struct {
 	nextRunSettings      []Setting
 	error                error
 	errorViewport        Section
+	searchInput          textinput.Model
+	searching            bool
+	searchStart          int
+	// Cancels the query being executed.
+	cancelQuery context.CancelCauseFunc
+	// Quit once the cancelled query has stopped.
+	quitting bool
+	// EXPLAIN options of the next run, checked against the server version.
+	nextRunOptions ExplainOptions
+	serverVersion  int
 }
 
 func InitModel(source Source) Model {
@@ -191,6 +325,8 @@ func InitModel(source Source) Model {
 	thisRunSettings := NewSection("Settings", 80, 7)
 	nextRunSettings.subtitle = ctx.SettingsStyles.SelectedSettingsType.Render(" Next Run ")
 	thisRunSettings.subtitle = ctx.SettingsStyles.SelectedSettingsType.Render(" This Run ")
+	planSettings := NewSection("Settings", 80, 7)
+	planSettings.subtitle = ctx.SettingsStyles.SelectedSettingsType.Render(" Plan ")
 	sqlViewport := NewSection("SQL", 80, 10)
 
 	return Model{
@@ -199,16 +335,44 @@ func InitModel(source Source) Model {
 		help:                 help.New(),
 		sqlHelp:              help.New(),
 		detailsViewport:      NewSection("Details", 80, 10),
+		queryViewport:        NewSection("Query", 80, 17),
 		sqlViewport:          sqlViewport,
 		nextSettingsViewport: nextRunSettings,
 		thisSettingsViewport: thisRunSettings,
+		planSettingsViewport: planSettings,
 		source:               source,
 		originalSource:       source,
 		spinner:              initialSpinner(),
 		errorViewport:        NewSection("!Error!", 80, 7),
+		searchInput:          initialSearchInput(),
+		nextRunOptions:       RunExplainOptions,
 	}
 }
 
+// Cause of cancelling a query to quit the program, the plan isn't estimated
+// then.
+var errQuitting = errors.New("quitting")
+
+// Longest the plan of a cancelled query may take to be estimated.
+var estimateTimeout = 10 * time.Second
+
+// queryContext starts the context of a query to execute, replacing that of
+// the previous query.
+func (m *Model) queryContext() context.Context {
+	if m.cancelQuery != nil {
+		m.cancelQuery(nil)
+	}
+	ctx, cancel := context.WithCancelCause(context.Background())
+	m.cancelQuery = cancel
+	return ctx
+}
+
+func initialSearchInput() textinput.Model {
+	input := textinput.New()
+	input.Prompt = "/"
+	return input
+}
+
 func initialSpinner() spinner.Model {
 	s := spinner.New()
 	s.Spinner = spinner.Dot
@@ -217,18 +381,58 @@ func initialSpinner() spinner.Model {
 }
 
 func (m *Model) UpdateModel(explainPlan ExplainPlan) {
+	m.explainPlan = explainPlan
 	m.nodes = explainPlan.nodes
+	m.ctx.Folded = nil
+	m.ctx.BarMaxima = NewBarMaxima(explainPlan)
 	m.SetDisplayNodes(displayedNodes(explainPlan.nodes, m.ctx))
 	m.StatusLine = NewStatusLine(explainPlan)
 }
 
+func (m *Model) SetError(err error) {
+	m.error = err
+	m.errorViewport.SetContent(ansi.Wordwrap(err.Error(), m.ctx.Width-10, ""))
+}
+
 func (m *Model) SetDisplayNodes(nodes []PlanNode) {
 	m.DisplayNodes = displayedNodes(nodes, m.ctx)
 	m.setSqlViewHeight()
 }
 
 func (m *Model) setSqlViewHeight() {
-	m.sqlViewport.SetDimensions(m.ctx.Width-1, m.ctx.Height-len(m.DisplayNodes)-13)
+	m.sqlViewport.SetDimensions(m.ctx.Width-1, m.ctx.Height-m.sqlNodeListHeight()-sqlSectionChrome)
+}
+
+// refreshDisplayNodes recomputes the displayed nodes after folding, keeping the
+// cursor on the selected node or on its closest displayed ancestor.
+func (m *Model) refreshDisplayNodes() {
+	m.SetDisplayNodes(m.nodes)
+	if len(m.DisplayNodes) == 0 {
+		m.ctx.Cursor = 0
+		m.ctx.SelectedNode = PlanNode{}
+		return
+	}
+
+	id := m.ctx.SelectedNode.Position.Id
+	for id != 0 {
+		for i, node := range m.DisplayNodes {
+			if node.Position.Id == id {
+				m.ctx.Cursor = i
+				m.ctx.SelectedNode = node
+				return
+			}
+		}
+		id = m.nodes[id-1].ViewPosition(m.ctx).Parent
+	}
+
+	m.ctx.Cursor = 0
+	m.ctx.SelectedNode = m.DisplayNodes[0]
+}
+
+// scrollToCursor remembers the window of the plan tree so that it only moves
+// when the cursor leaves it.
+func (m *Model) scrollToCursor() {
+	m.ctx.NodeOffset, _ = m.nodeWindow()
 }
 
 type SourceType int
@@ -275,9 +479,13 @@ func RunProgram(source Source, teaOpts ...tea.ProgramOption) *tea.Program {
 	model := InitModel(source)
 
 	if source.sourceType == SOURCE_STDIN {
-		explainPlan := Convert(source.input)
-		model.UpdateModel(explainPlan)
-		model.ctx.ResetContext(explainPlan, model)
+		explainPlan, err := Convert(source.input)
+		if err != nil {
+			model.SetError(err)
+		} else {
+			model.UpdateModel(explainPlan)
+			model.ctx.ResetContext(explainPlan, model)
+		}
 	}
 
 	program := tea.NewProgram(
@@ -328,14 +536,44 @@ type errorMsg struct {
 	error error
 }
 
-func ExecuteQueryCmd(fileName string, settings []Setting) tea.Cmd {
+// ExecuteQueryCmd explains and analyzes the query, the query is cancelled
+// along with the context. A cancelled query is recorded with its elapsed time
+// and the plan estimated without running it.
+func ExecuteQueryCmd(ctx context.Context, fileName string, settings []Setting, options ExplainOptions) tea.Cmd {
 	return func() tea.Msg {
-		queryRun := NewQueryRun(fileName)
-		queryWithExplain := queryRun.WithExplainAnalyze()
+		queryRun, err := NewQueryRun(fileName, RunStatement)
+		if err != nil {
+			return errorMsg{error: err}
+		}
+		queryRun.options = options
+		queryRun.optionsRecorded = true
+		queryRun.params = QueryParams(queryRun.query, RunParams)
+		queryRun.rolledBack = !RunCommit && queryRun.changesData(options.Analyze)
+		queryWithExplain, err := queryRun.WithExplainAnalyze()
+		if err != nil {
+			return errorMsg{error: err}
+		}
 		var queryRunSettings = make([]Setting, 5, 5)
 		copy(queryRunSettings, settings)
 		queryRun.settings = queryRunSettings
-		result, err := ExecuteExplain(queryWithExplain, settings)
+		start := time.Now()
+		result, err := ExecuteExplain(ctx, queryRun.SetupStatements(), queryWithExplain, settings, queryRun.rolledBack)
+		if reason := cancelReason(err); reason != "" {
+			// Nothing is shown once the program quits.
+			if errors.Is(context.Cause(ctx), errQuitting) {
+				return errorMsg{error: err}
+			}
+			queryRun.cancelled = reason
+			queryRun.elapsed = time.Since(start)
+			estimateCtx, cancel := context.WithTimeout(context.Background(), estimateTimeout)
+			defer cancel()
+			// The params were already checked for the analyzed query.
+			queryWithExplain, _ = queryRun.WithExplain()
+			result, err = ExecuteExplain(estimateCtx, queryRun.SetupStatements(), queryWithExplain, settings, true)
+			if err != nil {
+				return errorMsg{error: fmt.Errorf("query %s after %s, the plan could not be estimated: %w", reason, queryRun.elapsed.Round(time.Millisecond), err)}
+			}
+		}
 		if err != nil {
 			return errorMsg{error: err}
 		}
@@ -344,7 +582,7 @@ func ExecuteQueryCmd(fileName string, settings []Setting) tea.Cmd {
 		if err != nil {
 			return errorMsg{error: err}
 		}
-		queryRun.WritePgexFile(pgexDir)
+		err = queryRun.WritePgexFile(pgexDir)
 		if err != nil {
 			return errorMsg{error: err}
 		}
@@ -356,14 +594,25 @@ type executeExplainQueryMsg struct {
 	queryRun QueryRun
 }
 
-func ExecuteExplainQueryCmd(fileName string, settings []Setting) tea.Cmd {
+func ExecuteExplainQueryCmd(ctx context.Context, fileName string, settings []Setting, options ExplainOptions) tea.Cmd {
 	return func() tea.Msg {
-		queryRun := NewQueryRun(fileName)
-		queryWithExplain := queryRun.WithExplain()
+		queryRun, err := NewQueryRun(fileName, RunStatement)
+		if err != nil {
+			return errorMsg{error: err}
+		}
+		queryRun.options = options
+		queryRun.optionsRecorded = true
+		queryRun.params = QueryParams(queryRun.query, RunParams)
+		queryWithExplain, err := queryRun.WithExplain()
+		if err != nil {
+			return errorMsg{error: err}
+		}
 		var queryRunSettings = make([]Setting, 5, 5)
 		copy(queryRunSettings, settings)
 		queryRun.settings = queryRunSettings
-		result, err := ExecuteExplain(queryWithExplain, settings)
+		// The setup is run again by the analyze, so it is always rolled back
+		// here, only the analyze keeps its changes with --commit.
+		result, err := ExecuteExplain(ctx, queryRun.SetupStatements(), queryWithExplain, settings, true)
 		if err != nil {
 			return errorMsg{error: err}
 		}
@@ -373,28 +622,34 @@ func ExecuteExplainQueryCmd(fileName string, settings []Setting) tea.Cmd {
 }
 
 type showAllMsg struct {
-	settings []Setting
+	settings      []Setting
+	serverVersion int
 }
 
 func ShowAllCmd() tea.Msg {
-	settings, err := ShowAll()
+	settings, serverVersion, err := ShowAll()
 	if err != nil {
 		return errorMsg{error: err}
 	}
 	slices.SortFunc(settings, SettingCompare)
-	return showAllMsg{settings: settings}
+	return showAllMsg{settings: settings, serverVersion: serverVersion}
 }
 
-func ShowAll() ([]Setting, error) {
+func ShowAll() ([]Setting, int, error) {
 	pgConn := Connection{
 		connConfig: ConnConfig,
 	}
-	err := pgConn.Connect()
+	err := pgConn.Connect(context.Background())
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer pgConn.Close()
-	return pgConn.ShowAll()
+	serverVersion, err := pgConn.ServerVersionNum()
+	if err != nil {
+		return nil, 0, err
+	}
+	settings, err := pgConn.ShowAll()
+	return settings, serverVersion, err
 }
 
 func (m Model) Init() tea.Cmd {
@@ -409,29 +664,53 @@ func (m Model) Init() tea.Cmd {
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
+	if msg, ok := msg.(tea.KeyMsg); ok && m.searching {
+		return m.updateSearch(msg)
+	}
+
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.Quit):
+			// Stop the query on the server before quitting.
+			if m.loading && m.cancelQuery != nil {
+				m.cancelQuery(errQuitting)
+				m.quitting = true
+				return m, nil
+			}
 			return m, tea.Quit
+		case key.Matches(msg, m.keys.CancelQuery):
+			if m.loading && m.cancelQuery != nil {
+				m.cancelQuery(nil)
+			}
 		case key.Matches(msg, m.keys.IndentToggle):
 			m.ctx.Indent = !m.ctx.Indent
 		case key.Matches(msg, m.keys.Up):
+			if len(m.DisplayNodes) == 0 {
+				return m, nil
+			}
 			if m.ctx.Cursor-1 >= 0 {
 				m.ctx.Cursor = m.ctx.Cursor - 1
 				m.ctx.SelectedNode = m.DisplayNodes[m.ctx.Cursor]
 			}
 		case key.Matches(msg, m.keys.Down):
+			if len(m.DisplayNodes) == 0 {
+				return m, nil
+			}
 			if m.ctx.Cursor+1 < len(m.DisplayNodes) {
 				m.ctx.Cursor = m.ctx.Cursor + 1
 				m.ctx.SelectedNode = m.DisplayNodes[m.ctx.Cursor]
 			}
 		case key.Matches(msg, m.keys.SettingsUp):
-			if m.ctx.SettingsCursor-1 >= 0 {
+			if m.ctx.DisplayOptions {
+				m.ctx.OptionsCursor = max(0, m.ctx.OptionsCursor-1)
+			} else if m.ctx.SettingsCursor-1 >= 0 {
 				m.ctx.SettingsCursor = m.ctx.SettingsCursor - 1
 			}
 		case key.Matches(msg, m.keys.SettingsDown):
-			if m.ctx.SettingsCursor+1 < len(m.nextRunSettings) {
+			if m.ctx.DisplayOptions {
+				m.ctx.OptionsCursor = min(len(explainOptions)-1, m.ctx.OptionsCursor+1)
+			} else if m.ctx.SettingsCursor+1 < len(m.nextRunSettings) {
 				m.ctx.SettingsCursor = m.ctx.SettingsCursor + 1
 			}
 		case key.Matches(msg, m.keys.Help):
@@ -445,23 +724,101 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			} else {
 				m.ctx.SelectedNode = PlanNode{}
 			}
+		case key.Matches(msg, m.keys.Parent):
+			m.moveToParent()
+		case key.Matches(msg, m.keys.FirstChild):
+			m.moveToFirstChild()
+		case key.Matches(msg, m.keys.NextSibling):
+			m.moveToSibling(1)
+		case key.Matches(msg, m.keys.PrevSibling):
+			m.moveToSibling(-1)
+		case key.Matches(msg, m.keys.Top):
+			m.selectDisplayed(0)
+		case key.Matches(msg, m.keys.Bottom):
+			m.selectDisplayed(len(m.DisplayNodes) - 1)
+		case key.Matches(msg, m.keys.WorstTime):
+			if m.ctx.Analyzed {
+				m.moveToWorst(exclusiveTimeScore)
+			}
+		case key.Matches(msg, m.keys.WorstEstimate):
+			if m.ctx.Analyzed && m.ctx.Costs {
+				m.moveToWorst(misestimateScore)
+			}
+		case key.Matches(msg, m.keys.MostRead):
+			m.moveToWorst(selfBuffersReadScore(m.nodes))
+		case key.Matches(msg, m.keys.Search):
+			m.searching = true
+			m.searchInput.SetValue("")
+			m.searchStart = m.ctx.Cursor
+			return m, m.searchInput.Focus()
+		case key.Matches(msg, m.keys.NextMatch):
+			m.moveToMatch(m.ctx.Cursor+1, 1)
+		case key.Matches(msg, m.keys.PrevMatch):
+			m.moveToMatch(m.ctx.Cursor-1, -1)
+		case key.Matches(msg, m.keys.ToggleFilter):
+			if m.ctx.SearchPattern != "" || m.ctx.FilterMatches {
+				m.ctx.FilterMatches = !m.ctx.FilterMatches
+				m.refreshDisplayNodes()
+			}
+		case key.Matches(msg, m.keys.ToggleFold):
+			if m.ctx.SelectedNode.Position.Id != 0 && (m.ctx.Folded[m.ctx.SelectedNode.Position.Id] || hasChildren(m.ctx.SelectedNode, m.nodes, m.ctx)) {
+				folded := maps.Clone(m.ctx.Folded)
+				if folded == nil {
+					folded = map[int]bool{}
+				}
+				folded[m.ctx.SelectedNode.Position.Id] = !folded[m.ctx.SelectedNode.Position.Id]
+				m.ctx.Folded = folded
+				m.refreshDisplayNodes()
+			}
+		case key.Matches(msg, m.keys.FoldBelowDepth):
+			depth := int(msg.String()[0] - '0')
+			m.ctx.Folded = foldBelowDepth(m.nodes, depth, m.ctx)
+			m.refreshDisplayNodes()
+		case key.Matches(msg, m.keys.UnfoldAll):
+			m.ctx.Folded = nil
+			m.refreshDisplayNodes()
 		case key.Matches(msg, m.keys.NextStatDisplay):
 			m.ctx.StatDisplay = nextStatDisplay(m.ctx)
 		case key.Matches(msg, m.keys.PrevStatDisplay):
 			m.ctx.StatDisplay = prevStatDisplay(m.ctx)
+		case key.Matches(msg, m.keys.NextBuffersDisplay):
+			if m.ctx.StatDisplay == DisplayBuffers {
+				m.ctx.BuffersDisplay = (m.ctx.BuffersDisplay + 1) % buffersViewCount
+			}
+		case key.Matches(msg, m.keys.ToggleBars):
+			m.ctx.DisplayBars = !m.ctx.DisplayBars
 		case key.Matches(msg, m.keys.ToggleParallel):
 			m.ctx.DisplayParallel = !m.ctx.DisplayParallel
 		case key.Matches(msg, m.keys.ToggleNumbers):
 			m.ctx.DisplayNumbers = !m.ctx.DisplayNumbers
 		case key.Matches(msg, m.keys.ToggleDisplaySql):
 			m.ctx.DisplaySql = !m.ctx.DisplaySql
+		case key.Matches(msg, m.keys.ToggleDisplayQuery):
+			m.ctx.DisplayQuery = !m.ctx.DisplayQuery
 		case key.Matches(msg, m.keys.ToggleRelations):
 			m.ctx.DisplayRelations = !m.ctx.DisplayRelations
+		case key.Matches(msg, m.keys.ToggleIcicle):
+			if m.ctx.Analyzed {
+				m.ctx.DisplayIcicle = !m.ctx.DisplayIcicle
+			}
 		case key.Matches(msg, m.keys.ReExecute):
-			if m.originalSource.sourceType == SOURCE_FILE {
+			if m.originalSource.sourceType == SOURCE_FILE && !m.loading {
+				// The options panel shows why the options can't be used.
+				if m.nextRunOptions.Validate(m.serverVersion) != nil {
+					m.ctx.DisplayOptions = true
+					break
+				}
 				m.loading = true
 				m.stopwatch = stopwatch.NewWithInterval(time.Millisecond * 100)
-				return m, tea.Batch(m.stopwatch.Init(), m.spinner.Tick, ExecuteQueryCmd(m.originalSource.fileName, m.nextRunSettings))
+				return m, tea.Batch(m.stopwatch.Init(), m.spinner.Tick, ExecuteQueryCmd(m.queryContext(), m.originalSource.fileName, m.nextRunSettings, m.nextRunOptions))
+			}
+		case key.Matches(msg, m.keys.ToggleOptions):
+			m.ctx.DisplayOptions = !m.ctx.DisplayOptions
+		case key.Matches(msg, m.keys.SaveOptions):
+			if m.ctx.DisplayOptions {
+				if err := SaveExplainOptions(ExplainConfigPath(), m.nextRunOptions); err != nil {
+					m.SetError(err)
+				}
 			}
 		case key.Matches(msg, m.keys.PrevQueryRun):
 			return m, PreviousQueryRun(m.queryRun)
@@ -472,24 +829,42 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, m.keys.SqlDown):
 			m.sqlViewport.LineDown(1)
 		case key.Matches(msg, m.keys.SettingIncrement):
-			m.nextRunSettings[m.ctx.SettingsCursor].IncrementSetting()
+			if m.ctx.DisplayOptions {
+				if !Unsupported(m.ctx.OptionsCursor, m.serverVersion) {
+					m.nextRunOptions.Set(m.ctx.OptionsCursor, true)
+				}
+			} else {
+				m.nextRunSettings[m.ctx.SettingsCursor].IncrementSetting()
+			}
 		case key.Matches(msg, m.keys.SettingDecrement):
-			m.nextRunSettings[m.ctx.SettingsCursor].DecrementSetting()
+			if m.ctx.DisplayOptions {
+				m.nextRunOptions.Set(m.ctx.OptionsCursor, false)
+			} else {
+				m.nextRunSettings[m.ctx.SettingsCursor].DecrementSetting()
+			}
 		default:
 			return m, tea.Println(msg)
 		}
 	case showAllMsg:
 		m.nextRunSettings = msg.settings
+		m.serverVersion = msg.serverVersion
+		if err := m.nextRunOptions.Validate(m.serverVersion); err != nil {
+			m.SetError(err)
+			return m, nil
+		}
 		m.loading = true
-		return m, tea.Batch(m.spinner.Tick, ExecuteExplainQueryCmd(m.source.fileName, m.nextRunSettings))
+		return m, tea.Batch(m.spinner.Tick, ExecuteExplainQueryCmd(m.queryContext(), m.source.fileName, m.nextRunSettings, m.nextRunOptions))
 	case executeExplainQueryMsg:
 		UpdateModel(&m, msg.queryRun)
 		m.loading = true
 		m.stopwatch = stopwatch.NewWithInterval(time.Millisecond * 100)
-		return m, tea.Batch(m.stopwatch.Init(), ExecuteQueryCmd(m.source.fileName, m.nextRunSettings))
+		return m, tea.Batch(m.stopwatch.Init(), ExecuteQueryCmd(m.queryContext(), m.source.fileName, m.nextRunSettings, m.nextRunOptions))
 	case executeQueryMsg:
 		UpdateModel(&m, msg.queryRun)
 		m.loading = false
+		if m.quitting {
+			return m, tea.Quit
+		}
 		return m, tea.Batch(m.stopwatch.Stop(), m.stopwatch.Reset())
 	case newQueryRunMsg:
 		newQueryRun := msg.queryRun
@@ -499,9 +874,13 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 	case errorMsg:
-		m.error = msg.error
-		m.errorViewport.SetContent(msg.error.Error())
+		m.SetError(msg.error)
+		// The error takes room from the node list.
+		m.scrollToCursor()
 		m.loading = false
+		if m.quitting {
+			return m, tea.Quit
+		}
 		return m, m.stopwatch.Stop()
 	case spinner.TickMsg:
 		var cmd tea.Cmd
@@ -524,6 +903,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ctx.Height = msg.Height
 		m.setSqlViewHeight()
 		m.detailsViewport.SetDimensions(m.ctx.Width-1, 10)
+		m.queryViewport.SetDimensions(m.ctx.Width-1, 17)
 		m.thisSettingsViewport.SetDimensions((m.ctx.Width-1)/2, 7)
 		var nextSettingsWidth int
 		if m.ctx.Width%2 == 1 {
@@ -532,31 +912,114 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			nextSettingsWidth = (m.ctx.Width - 1) / 2
 		}
 		m.nextSettingsViewport.SetDimensions(nextSettingsWidth, 7)
+		m.planSettingsViewport.SetDimensions(m.ctx.Width-1, 7)
 	}
 
+	// The window only moves with the keys and the size of the screen, not with
+	// the spinner and stopwatch ticks. New plans reset it in UpdateModel.
+	switch msg.(type) {
+	case tea.KeyMsg, tea.WindowSizeMsg:
+		m.scrollToCursor()
+	}
 	return m, nil
 }
 
 func UpdateModel(m *Model, queryRun QueryRun) {
 	m.queryRun = queryRun
-	explainPlan := Convert(queryRun.result)
+	explainPlan, err := Convert(queryRun.result)
+	if err != nil {
+		m.SetError(err)
+		m.explainPlan = ExplainPlan{}
+		m.nodes = nil
+		m.SetDisplayNodes(nil)
+		m.StatusLine = StatusLine{}
+		m.ctx.SelectedNode = PlanNode{}
+		m.ctx.Cursor = 0
+		m.ctx.NodeOffset = 0
+		return
+	}
+	m.error = nil
 	m.UpdateModel(explainPlan)
 	m.ctx.ResetContext(explainPlan, *m)
 	m.ctx.SelectedNode = m.DisplayNodes[0]
-	wrappedSql := ansi.Wordwrap(queryRun.query, m.ctx.Width-10, "") + "\n"
+	wrappedSql := ansi.Wordwrap(queryRun.DisplaySql(), m.ctx.Width-10, "") + "\n"
 	m.sqlViewport.SetContent(wrappedSql)
 }
 
+// updateSearch handles keys while the search pattern is typed, moving the
+// cursor to the first match as the pattern changes.
+func (m Model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEnter:
+		m.searching = false
+		m.searchInput.Blur()
+		return m, nil
+	case tea.KeyEsc:
+		m.searching = false
+		m.searchInput.Blur()
+		m.ctx.SearchPattern = ""
+		m.ctx.FilterMatches = false
+		m.refreshDisplayNodes()
+		m.scrollToCursor()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != m.ctx.SearchPattern {
+		m.ctx.SearchPattern = m.searchInput.Value()
+		if m.ctx.FilterMatches {
+			m.refreshDisplayNodes()
+		}
+		m.moveToMatch(m.searchStart, 1)
+		m.scrollToCursor()
+	}
+	return m, cmd
+}
+
+// moveToMatch moves the cursor to the first displayed node matching the search
+// pattern, looking from the start position in the direction and wrapping
+// around the ends of the list.
+func (m *Model) moveToMatch(start int, direction int) {
+	count := len(m.DisplayNodes)
+	if m.ctx.SearchPattern == "" || count == 0 {
+		return
+	}
+
+	for i := range count {
+		index := ((start+i*direction)%count + count) % count
+		if m.DisplayNodes[index].Matches(m.ctx.SearchPattern) {
+			m.ctx.Cursor = index
+			m.ctx.SelectedNode = m.DisplayNodes[index]
+			return
+		}
+	}
+}
+
+// matchCount is the number of displayed nodes matching the search pattern.
+func (m Model) matchCount() int {
+	count := 0
+	for _, node := range m.DisplayNodes {
+		if node.Matches(m.ctx.SearchPattern) {
+			count++
+		}
+	}
+	return count
+}
+
 func prevStatDisplay(ctx ProgramContext) StatView {
 	newStatDisplay := ctx.StatDisplay
 
 	for true {
 		if newStatDisplay == 0 {
-			newStatDisplay = DisplayCost
+			newStatDisplay = statViewCount - 1
 		} else {
-			newStatDisplay = (newStatDisplay - 1) % 5
+			newStatDisplay = (newStatDisplay - 1) % statViewCount
 		}
 
+		if newStatDisplay == DisplayCost && !ctx.Costs {
+			continue
+		}
 		if ctx.Analyzed {
 			break
 		} else if slices.Contains([]StatView{DisplayRows, DisplayCost, DisplayNothing}, newStatDisplay) {
@@ -570,8 +1033,11 @@ func nextStatDisplay(ctx ProgramContext) StatView {
 	newStatDisplay := ctx.StatDisplay
 
 	for true {
-		newStatDisplay = (newStatDisplay + 1) % 5
+		newStatDisplay = (newStatDisplay + 1) % statViewCount
 
+		if newStatDisplay == DisplayCost && !ctx.Costs {
+			continue
+		}
 		if ctx.Analyzed {
 			break
 		} else if slices.Contains([]StatView{DisplayRows, DisplayCost, DisplayNothing}, newStatDisplay) {
@@ -584,15 +1050,90 @@ func nextStatDisplay(ctx ProgramContext) StatView {
 func displayedNodes(nodes []PlanNode, ctx ProgramContext) []PlanNode {
 	resultNodes := make([]PlanNode, 0, len(nodes))
 
+	var matching map[int]bool
+	if ctx.FilterMatches && ctx.SearchPattern != "" {
+		matching = matchingWithAncestors(nodes, ctx)
+	}
+
 	for _, node := range nodes {
-		if node.Display(ctx) {
+		if !node.Display(ctx) {
+			continue
+		}
+		if matching != nil && !matching[node.Position.Id] {
+			continue
+		}
+
+		folded := foldedAncestor(node, nodes, ctx)
+		if folded == 0 {
 			resultNodes = append(resultNodes, node)
+			continue
+		}
+
+		// Count the hidden node on the folded node that is displayed.
+		for i := range resultNodes {
+			if resultNodes[i].Position.Id == folded {
+				resultNodes[i].foldedCount++
+			}
 		}
 	}
 
 	return resultNodes
 }
 
+// matchingWithAncestors returns the Ids of the nodes matching the search
+// pattern along with those of their ancestors, which keep the tree intact when
+// filtering.
+func matchingWithAncestors(nodes []PlanNode, ctx ProgramContext) map[int]bool {
+	matching := map[int]bool{}
+	for _, node := range nodes {
+		if !node.Display(ctx) || !node.Matches(ctx.SearchPattern) {
+			continue
+		}
+		id := node.Position.Id
+		for id != 0 && !matching[id] {
+			matching[id] = true
+			id = nodes[id-1].ViewPosition(ctx).Parent
+		}
+	}
+	return matching
+}
+
+// foldedAncestor returns the Id of the outermost folded ancestor of the node,
+// or 0 when none of its ancestors are folded.
+func foldedAncestor(node PlanNode, nodes []PlanNode, ctx ProgramContext) int {
+	folded := 0
+	parent := node.ViewPosition(ctx).Parent
+	for parent != 0 {
+		if ctx.Folded[parent] {
+			folded = parent
+		}
+		parent = nodes[parent-1].ViewPosition(ctx).Parent
+	}
+	return folded
+}
+
+// hasChildren reports whether any displayed node is a child of the node.
+func hasChildren(node PlanNode, nodes []PlanNode, ctx ProgramContext) bool {
+	for _, n := range nodes {
+		if n.Display(ctx) && n.ViewPosition(ctx).Parent == node.Position.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// foldBelowDepth folds every node at the depth that has children, hiding all
+// the nodes below it.
+func foldBelowDepth(nodes []PlanNode, depth int, ctx ProgramContext) map[int]bool {
+	folded := map[int]bool{}
+	for _, node := range nodes {
+		if node.Display(ctx) && node.ViewPosition(ctx).Level == depth && hasChildren(node, nodes, ctx) {
+			folded[node.Position.Id] = true
+		}
+	}
+	return folded
+}
+
 func (m Model) View() string {
 	var buf strings.Builder
 
@@ -608,18 +1149,40 @@ func (m Model) View() string {
 
 	spaceAvailable := m.ctx.Width - ansi.StringWidth(sourceView)
 
-	buf.WriteString(fmt.Sprintf("%*s%*s\n", spaceAvailable-10, m.ctx.StatDisplay.String(), 10, ""))
+	statTitle := m.ctx.StatDisplay.String()
+	if m.ctx.StatDisplay == DisplayBuffers {
+		statTitle = fmt.Sprintf("%s %s", statTitle, m.ctx.BuffersDisplay)
+	}
+	buf.WriteString(fmt.Sprintf("%*s%*s\n", spaceAvailable-10, statTitle, 10, ""))
 
 	statusLine := m.StatusLine.View(m)
 	buf.WriteString(statusLine)
+	if m.ctx.DisplayIcicle && m.ctx.Analyzed && m.error == nil {
+		buf.WriteString("\n")
+		buf.WriteString(m.icicleView())
+		return buf.String()
+	}
 	buf.WriteString(HeadersView(m.ctx, m.ctx.Width-ansi.StringWidth(statusLine)-1))
 	buf.WriteString("\n")
 
-	for i, node := range m.DisplayNodes {
-		buf.WriteString(node.View(i, m.ctx))
+	bottom := m.bottomView()
+
+	offset, rows := m.nodeWindow()
+	for i, node := range m.DisplayNodes[offset : offset+rows] {
+		buf.WriteString(node.View(offset+i, m.ctx))
 	}
 
 	buf.WriteString("\n")
+	buf.WriteString(bottom)
+	buf.WriteString("\n")
+
+	return buf.String()
+}
+
+// bottomView renders the sections below the plan tree.
+func (m Model) bottomView() string {
+	var buf strings.Builder
+
 	if m.error != nil {
 		buf.WriteString(m.errorViewport.View())
 	} else if m.ctx.DisplaySql {
@@ -627,45 +1190,155 @@ func (m Model) View() string {
 		buf.WriteString("\n")
 		buf.WriteString(m.sqlHelp.ShortHelpView(keys.SqlShortHelp()))
 	} else {
-		m.detailsViewport.SetContent(m.ctx.SelectedNode.Content(m.ctx))
-		m.detailsViewport.subtitle = m.ctx.NormalStyle.NodeName.Render(m.ctx.SelectedNode.Name())
-		buf.WriteString(m.detailsViewport.View())
-		buf.WriteString("\n")
-		if slices.Contains([]SourceType{SOURCE_PGEX, SOURCE_FILE}, m.source.sourceType) {
-			m.thisSettingsViewport.SetContent(SettingsView(m.queryRun.settings, m.ctx, false))
-			m.nextSettingsViewport.SetContent(SettingsView(m.nextRunSettings, m.ctx, true))
-			buf.WriteString(lipgloss.JoinHorizontal(1, m.thisSettingsViewport.View(), " ", m.nextSettingsViewport.View()))
+		if m.ctx.DisplayQuery {
+			m.queryViewport.SetContent(m.explainPlan.Content(m.ctx))
+			buf.WriteString(m.queryViewport.View())
+		} else {
+			m.detailsViewport.SetContent(m.ctx.SelectedNode.Content(m.ctx))
+			m.detailsViewport.subtitle = m.ctx.NormalStyle.NodeName.Render(m.ctx.SelectedNode.Name())
+			buf.WriteString(m.detailsViewport.View())
+			buf.WriteString("\n")
+			if slices.Contains([]SourceType{SOURCE_PGEX, SOURCE_FILE}, m.source.sourceType) && m.ctx.DisplayOptions {
+				m.thisSettingsViewport.title = "Explain Options"
+				m.nextSettingsViewport.title = "Explain Options"
+				m.thisSettingsViewport.SetDimensions(m.thisSettingsViewport.viewport.Width, optionsSectionHeight)
+				m.nextSettingsViewport.SetDimensions(m.nextSettingsViewport.viewport.Width, optionsSectionHeight)
+				m.thisSettingsViewport.SetContent(m.thisRunOptionsView())
+				nextRunOptions := ExplainOptionsView(m.nextRunOptions, m.queryRun.options, m.ctx, true, m.serverVersion)
+				if err := m.nextRunOptions.Validate(m.serverVersion); err != nil {
+					nextRunOptions += m.ctx.NormalStyle.Warning.Render(ansi.Wordwrap(err.Error(), m.nextSettingsViewport.viewport.Width-6, "")) + "\n"
+				}
+				m.nextSettingsViewport.SetContent(nextRunOptions)
+				buf.WriteString(lipgloss.JoinHorizontal(1, m.thisSettingsViewport.View(), " ", m.nextSettingsViewport.View()))
+			} else if slices.Contains([]SourceType{SOURCE_PGEX, SOURCE_FILE}, m.source.sourceType) {
+				thisRunSettings := MergeSettings(m.queryRun.settings, m.explainPlan.settings)
+				m.thisSettingsViewport.SetContent(SettingsView(thisRunSettings, nil, m.ctx, false))
+				m.nextSettingsViewport.SetContent(SettingsView(m.nextRunSettings, thisRunSettings, m.ctx, true))
+				buf.WriteString(lipgloss.JoinHorizontal(1, m.thisSettingsViewport.View(), " ", m.nextSettingsViewport.View()))
+			} else if len(m.explainPlan.settings) > 0 {
+				m.planSettingsViewport.SetContent(SettingsView(m.explainPlan.settings, nil, m.ctx, false))
+				buf.WriteString(m.planSettingsViewport.View())
+			}
 		}
 		buf.WriteString("\n")
-		buf.WriteString(m.help.View(m.keys))
+		if m.searching || m.ctx.SearchPattern != "" {
+			buf.WriteString(m.searchView())
+		} else {
+			buf.WriteString(m.help.View(m.keys))
+		}
+	}
+
+	return buf.String()
+}
+
+func (m Model) searchView() string {
+	var buf strings.Builder
+	if m.searching {
+		buf.WriteString(m.searchInput.View())
+	} else {
+		buf.WriteString(m.ctx.NormalStyle.Everything.Render("/" + m.ctx.SearchPattern))
 	}
-	buf.WriteString("\n")
 
+	status := fmt.Sprintf("  %d matches", m.matchCount())
+	if m.ctx.FilterMatches {
+		status += ", filtered"
+	}
+	buf.WriteString(m.ctx.NormalStyle.Gutter.Render(status))
 	return buf.String()
 }
 
+// Lines of the screen that aren't the plan tree or the bottom sections: the
+// title, the status line, the blank line below the tree and the final newline.
+var nodeListChrome = 4
+
+// Lines of the screen besides the plan tree and the SQL itself when the SQL
+// section is displayed.
+var sqlSectionChrome = 13
+
+// Smallest height of the SQL section, which otherwise takes whatever space the
+// plan tree leaves.
+var minSqlHeight = 6
+
+// nodeListHeight is the number of nodes that fit on the screen.
+func (m Model) nodeListHeight() int {
+	if m.ctx.DisplaySql && m.error == nil {
+		return m.sqlNodeListHeight()
+	}
+	return m.fitNodeList(m.ctx.Height - nodeListChrome - lipgloss.Height(m.bottomView()))
+}
+
+// sqlNodeListHeight is the number of nodes shown above the SQL section.
+func (m Model) sqlNodeListHeight() int {
+	return m.fitNodeList(m.ctx.Height - sqlSectionChrome - minSqlHeight)
+}
+
+func (m Model) fitNodeList(available int) int {
+	// Before the first WindowSizeMsg the height is unknown.
+	if m.ctx.Height == 0 {
+		available = len(m.DisplayNodes)
+	}
+	return max(min(len(m.DisplayNodes), available), min(len(m.DisplayNodes), 3))
+}
+
+// nodeWindow returns the first displayed node and the number of nodes shown,
+// the window starts at ctx.NodeOffset moved just enough to show the cursor.
+func (m Model) nodeWindow() (int, int) {
+	rows := m.nodeListHeight()
+	offset := m.ctx.NodeOffset
+	if m.ctx.Cursor < offset {
+		offset = m.ctx.Cursor
+	} else if m.ctx.Cursor >= offset+rows {
+		offset = m.ctx.Cursor - rows + 1
+	}
+	offset = max(0, min(offset, len(m.DisplayNodes)-rows))
+	return offset, rows
+}
+
 func HeadersView(ctx ProgramContext, spaceAvailable int) string {
 	var headers string
 	if ctx.StatDisplay == DisplayTime {
 		headers = fmt.Sprintf("%10s%15s ", "Startup", "Total")
+	} else if ctx.StatDisplay == DisplayExclusive {
+		headers = fmt.Sprintf("%10s%15s ", "Self", "% Query")
 	} else if ctx.StatDisplay == DisplayCost {
 		headers = fmt.Sprintf("%10s%15s ", "Startup", "Total")
 	} else if ctx.StatDisplay == DisplayBuffers {
-		headers = fmt.Sprintf("%10s%15s ", "Total", "Read")
+		headers = buffersHeaders(ctx.BuffersDisplay)
+	} else if ctx.StatDisplay == DisplayWAL {
+		headers = fmt.Sprintf("%10s%15s%13s ", "Records", "FPI", "Bytes")
+	} else if ctx.StatDisplay == DisplayRows && ctx.Analyzed {
+		headers = fmt.Sprintf("%10s%15s%13s ", "Planned", "Actual", "Estimate")
 	} else if ctx.StatDisplay == DisplayRows {
 		headers = fmt.Sprintf("%10s%15s ", "Planned", "Actual")
 	} else if ctx.StatDisplay == DisplayNothing {
 		headers = ""
 	}
+	headers += strings.Repeat(" ", barSpace(ctx))
 	return fmt.Sprintf("%*s", spaceAvailable, headers)
 }
 
-func SettingsView(settings []Setting, ctx ProgramContext, nextSettings bool) string {
+func buffersHeaders(buffersDisplay BuffersView) string {
+	switch buffersDisplay {
+	case BuffersDirtied:
+		return fmt.Sprintf("%10s%15s ", "Dirtied", "Written")
+	case BuffersTemp:
+		return fmt.Sprintf("%10s%15s ", "Read", "Written")
+	case BuffersIOTime:
+		return fmt.Sprintf("%10s%15s ", "Read ms", "Write ms")
+	}
+	return fmt.Sprintf("%10s%15s ", "Total", "Read")
+}
+
+// SettingsView lists the settings, highlighting those with a different value
+// in the compared settings.
+func SettingsView(settings []Setting, compared []Setting, ctx ProgramContext, nextSettings bool) string {
 	var buf strings.Builder
 
 	for i, setting := range settings {
 		if i == ctx.SettingsCursor && nextSettings {
 			buf.WriteString(ctx.SettingsStyles.SelectedSettingsType.Render(setting.View()))
+		} else if setting.Differs(compared) {
+			buf.WriteString(ctx.NormalStyle.Caution.Render(setting.View()))
 		} else {
 			buf.WriteString(ctx.NormalStyle.Everything.Render(setting.View()))
 		}
@@ -673,3 +1346,47 @@ func SettingsView(settings []Setting, ctx ProgramContext, nextSettings bool) str
 	}
 	return buf.String()
 }
+
+// Rows of the options panel, the options are listed down two columns.
+var optionsRows = 5
+
+// Height of the options sections, taller than the settings to leave room for
+// why the options can't be used.
+var optionsSectionHeight = 9
+
+// ExplainOptionsView lists the options with a mark for those that are on,
+// highlighting the options that differ from the compared options and dimming
+// those the server doesn't support.
+func ExplainOptionsView(options ExplainOptions, compared ExplainOptions, ctx ProgramContext, nextOptions bool, serverVersion int) string {
+	var buf strings.Builder
+
+	for row := range optionsRows {
+		for i := row; i < len(explainOptions); i += optionsRows {
+			mark := " "
+			if options.Get(i) {
+				mark = "x"
+			}
+			view := fmt.Sprintf("[%s] %-14s", mark, explainOptions[i].name)
+			if i == ctx.OptionsCursor && nextOptions {
+				buf.WriteString(ctx.SettingsStyles.SelectedSettingsType.Render(view))
+			} else if Unsupported(i, serverVersion) {
+				buf.WriteString(ctx.NormalStyle.Gutter.Render(view))
+			} else if options.Get(i) != compared.Get(i) {
+				buf.WriteString(ctx.NormalStyle.Caution.Render(view))
+			} else {
+				buf.WriteString(ctx.NormalStyle.Everything.Render(view))
+			}
+			buf.WriteString(" ")
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
+
+// thisRunOptionsView shows the options the displayed run was explained with.
+func (m Model) thisRunOptionsView() string {
+	if !m.queryRun.optionsRecorded {
+		return m.ctx.NormalStyle.Gutter.Render("Not recorded for this run") + "\n"
+	}
+	return ExplainOptionsView(m.queryRun.options, m.queryRun.options, m.ctx, false, 0)
+}
@@ -5,9 +5,12 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/acarl005/stripansi"
+	"github.com/charmbracelet/bubbles/spinner"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +54,227 @@ func TestStdinSource(t *testing.T) {
 	assert.Contains(t, stripansi.Strip(rendered[ROW_EX_NODE_1]), "Finalize Aggregate")
 	assert.Contains(t, stripansi.Strip(rendered[ROW_DETAILS_TITLE]), "Details  Finalize Aggregate")
 }
+
+func TestFoldNodes(t *testing.T) {
+	data, err := os.ReadFile("./testdata/partitions.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := InitProgramContext()
+
+	assert.Equal(t, 62, len(displayedNodes(plan.nodes, ctx)))
+
+	ctx.Folded = map[int]bool{2: true}
+	nodes := displayedNodes(plan.nodes, ctx)
+	assert.Equal(t, 2, len(nodes))
+	assert.Equal(t, 60, nodes[1].foldedCount)
+
+	ctx.Folded = foldBelowDepth(plan.nodes, 1, ctx)
+	assert.Equal(t, map[int]bool{1: true}, ctx.Folded)
+	nodes = displayedNodes(plan.nodes, ctx)
+	assert.Equal(t, 1, len(nodes))
+	assert.Equal(t, 61, nodes[0].foldedCount)
+}
+
+func TestNodeWindowKeepsCursorVisible(t *testing.T) {
+	model := newTestModel(t, "./testdata/partitions.json")
+	for range 30 {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	}
+
+	view := model.View()
+	assert.Equal(t, 40, lipgloss.Height(view))
+	assert.Contains(t, stripansi.Strip(view), "Relation Name: events_p28")
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	m := model.(Model)
+	assert.Equal(t, 0, m.ctx.Cursor)
+	assert.Equal(t, 1, len(m.DisplayNodes))
+}
+
+func TestKeysAfterFailedQueryRun(t *testing.T) {
+	model := newTestModel(t, "./testdata/partitions.json")
+	for range 4 {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	}
+
+	model, _ = model.Update(newQueryRunMsg{queryRun: QueryRun{result: "[{", pgexPointer: "broken.pgex"}})
+	m := model.(Model)
+	assert.Empty(t, m.DisplayNodes)
+	assert.Equal(t, 0, m.ctx.Cursor)
+	assert.Equal(t, 0, m.ctx.NodeOffset)
+
+	for _, k := range []string{"k", "j", "g", "G"} {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	assert.Equal(t, 0, model.(Model).ctx.Cursor)
+}
+
+func TestTicksKeepNodeWindow(t *testing.T) {
+	model := newTestModel(t, "./testdata/partitions.json")
+	m := model.(Model)
+	m.ctx.Cursor = 30
+	m.ctx.SelectedNode = m.DisplayNodes[30]
+
+	model, _ = m.Update(spinner.TickMsg{})
+	assert.Equal(t, 0, model.(Model).ctx.NodeOffset)
+
+	model, _ = model.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	assert.Greater(t, model.(Model).ctx.NodeOffset, 0)
+}
+
+func TestSearchNodes(t *testing.T) {
+	model := newTestModel(t, "./testdata/partitions.json")
+
+	for _, k := range []string{"/", "p", "4"} {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)})
+	}
+	m := model.(Model)
+	assert.Equal(t, "events_p4", m.ctx.SelectedNode.RelationName)
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m = model.(Model)
+	assert.Equal(t, "events_p40", m.ctx.SelectedNode.RelationName)
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("N")})
+	m = model.(Model)
+	assert.Equal(t, "events_p49", m.ctx.SelectedNode.RelationName)
+	// N moves between matches, the numbers are toggled with #.
+	assert.Equal(t, InitProgramContext().DisplayNumbers, m.ctx.DisplayNumbers)
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("#")})
+	assert.NotEqual(t, m.ctx.DisplayNumbers, model.(Model).ctx.DisplayNumbers)
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("F")})
+	m = model.(Model)
+	assert.Equal(t, 13, len(m.DisplayNodes))
+	assert.Equal(t, "Sort", m.DisplayNodes[0].Name())
+	assert.Equal(t, "Append", m.DisplayNodes[1].Name())
+	assert.Equal(t, "events_p49", m.ctx.SelectedNode.RelationName)
+	assert.Contains(t, stripansi.Strip(m.View()), "/p4  11 matches, filtered")
+}
+
+func TestTreeNavigation(t *testing.T) {
+	model := newTestModel(t, "./testdata/merge.json")
+
+	expectations := []struct {
+		key string
+		id  int
+	}{
+		{"l", 2}, {"l", 3}, {">", 4}, {">", 4}, {"<", 3}, {"h", 2}, {"G", 5}, {"g", 1}, {"e", 3},
+	}
+	for _, expectation := range expectations {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(expectation.key)})
+		assert.Equal(t, expectation.id, model.(Model).ctx.SelectedNode.Position.Id, expectation.key)
+	}
+}
+
+func TestJumpToWorstNodes(t *testing.T) {
+	model := newTestModel(t, "./testdata/subplanname.json")
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	assert.Equal(t, 4, model.(Model).ctx.SelectedNode.Position.Id)
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t")})
+	assert.Equal(t, 1, model.(Model).ctx.SelectedNode.Position.Id)
+
+	// Jumping reveals a node hidden in a folded subtree.
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	assert.Equal(t, 4, model.(Model).ctx.SelectedNode.Position.Id)
+	assert.Equal(t, 4, len(model.(Model).DisplayNodes))
+}
+
+func newTestModel(t *testing.T, fixture string) tea.Model {
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := Convert(string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := InitModel(Source{sourceType: SOURCE_STDIN})
+	m.UpdateModel(plan)
+	m.ctx.ResetContext(plan, m)
+	model, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 40})
+	return model
+}
+
+func TestIcicleView(t *testing.T) {
+	model := newTestModel(t, "./testdata/node_details.json")
+
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("j")})
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("l")})
+	assert.True(t, model.(Model).ctx.DisplayIcicle)
+	assert.Equal(t, 3, model.(Model).ctx.SelectedNode.Position.Id)
+
+	view := model.View()
+	assert.Equal(t, 40, lipgloss.Height(view))
+	assert.Contains(t, view, "Bitmap Heap Scan on orders o  self 62.931ms (26.6%)  total 70.443ms (30.4%)")
+	assert.NotContains(t, view, "Details")
+
+	// Plans without ANALYZE have no times to chart.
+	model = newTestModel(t, "./testdata/verbose_selfjoin.json")
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	assert.False(t, model.(Model).ctx.DisplayIcicle)
+}
+
+func TestRolledBackStatus(t *testing.T) {
+	model := newTestModel(t, "./testdata/analyze_buffers.json")
+	assert.NotContains(t, model.View(), "Rolled Back")
+
+	m := model.(Model)
+	m.queryRun.rolledBack = true
+	assert.Contains(t, m.StatusLine.View(m), " Rolled Back ")
+}
+
+func TestCancelledStatus(t *testing.T) {
+	model := newTestModel(t, "./testdata/analyze_buffers.json")
+	m := model.(Model)
+	m.queryRun.cancelled = "timed out"
+	m.queryRun.elapsed = 12300 * time.Millisecond
+	assert.Contains(t, m.StatusLine.View(m), " Timed out after 12.3s ")
+}
+
+func TestExplainOptionsPanel(t *testing.T) {
+	model := newTestModel(t, "./testdata/analyze_buffers.json")
+	m := model.(Model)
+	m.source = Source{sourceType: SOURCE_PGEX}
+	m.nextRunOptions = DefaultExplainOptions()
+	m.serverVersion = 160002
+	m.queryRun.options = DefaultExplainOptions()
+	m.queryRun.optionsRecorded = true
+
+	model, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("o")})
+	view := stripansi.Strip(model.View())
+	assert.Contains(t, view, "Explain Options")
+	assert.Contains(t, view, "[x] analyze")
+	assert.Contains(t, view, "[ ] memory")
+
+	// memory needs postgres 17 and can't be turned on.
+	for range 7 {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	assert.False(t, model.(Model).nextRunOptions.Memory)
+
+	for range 2 {
+		model, _ = model.Update(tea.KeyMsg{Type: tea.KeyCtrlJ})
+	}
+	model, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	assert.True(t, model.(Model).nextRunOptions.GenericPlan)
+	view = stripansi.Strip(model.View())
+	assert.Contains(t, view, "EXPLAIN options ANALYZE and GENERIC_PLAN")
+	assert.Contains(t, view, "cannot be used together")
+
+	m = model.(Model)
+	m.queryRun.optionsRecorded = false
+	assert.Contains(t, stripansi.Strip(m.View()), "Not recorded for this run")
+}